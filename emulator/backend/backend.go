@@ -0,0 +1,46 @@
+package backend
+
+// LinkDetails describes a point to point link between two nodes and the /29 subnet it uses
+type LinkDetails struct {
+	NetworkName string `parquet:"network_name"`
+	Subnet      string `parquet:"subnet"`
+	NodeOneId   string `parquet:"node_one_id"`
+	NodeOneIP   string `parquet:"node_one_ip"`
+	NodeTwoId   string `parquet:"node_two_id"`
+	NodeTwoIP   string `parquet:"node_two_ip"`
+}
+
+// NodeKind selects the image/setup used for a node
+type NodeKind int
+
+const (
+	Satellite NodeKind = iota
+	GroundStation
+)
+
+func (k NodeKind) String() string {
+	if k == GroundStation {
+		return "groundstation"
+	}
+	return "satellite"
+}
+
+// Backend is a runtime the emulated network is built on (podman, docker, ...).
+// Nodes are addressed by the name given to CreateNode. On every link the interface of a node
+// is named after the node on the other end, so "tc qdisc replace dev Sat75 ..." in Sat74 targets the link to Sat75.
+type Backend interface {
+	// Init connects to the runtime, it is called once before any other method
+	Init() error
+	// CreateNode creates and starts a node and returns the name it is addressed by
+	CreateNode(name string, kind NodeKind) (string, error)
+	// RemoveNode stops and removes a node
+	RemoveNode(name string) error
+	// SetupLink connects NodeOneId and NodeTwoId with the addresses of the link
+	SetupLink(link LinkDetails) error
+	// TearDownLink removes a link created by SetupLink
+	TearDownLink(link LinkDetails) error
+	// RunCommand executes command inside a node, arguments are separated by single spaces
+	RunCommand(node string, command string) error
+	// Cleanup removes every node and link the backend has created, including those left by a previous run
+	Cleanup() error
+}
@@ -0,0 +1,21 @@
+package backend
+
+import "strings"
+
+// Batcher is implemented by backends that can run many ip or tc commands in a single exec session.
+// lines are the commands without the tool name, as read by "ip -batch" and "tc -batch".
+// RunBatch returns once the tool has exited, with an error if any line failed.
+type Batcher interface {
+	RunBatch(node string, tool string, lines []string) error
+}
+
+// BatchTools are the tools whose commands can be batched
+var BatchTools = []string{"ip", "tc"}
+
+// BatchScript returns the command line that runs lines with "tool -force -batch" from a here document,
+// for backends that execute argument lists in a container. -force keeps going after a line fails,
+// the tool still exits with a non zero code then.
+func BatchScript(tool string, lines []string) []string {
+	script := tool + " -force -batch - <<'EOF'\n" + strings.Join(lines, "\n") + "\nEOF"
+	return []string{"sh", "-c", script}
+}
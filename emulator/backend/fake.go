@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Call is one method call recorded by Fake
+type Call struct {
+	Method  string
+	Node    string
+	Link    string
+	Command string
+}
+
+// Fake is an in-memory Backend which records every call, so the engine and routing can be tested without a container runtime.
+// It keeps track of nodes and links and returns an error on calls a real runtime would reject.
+type Fake struct {
+	mu    sync.Mutex
+	calls []Call
+	nodes map[string]NodeKind
+	links map[string]LinkDetails
+}
+
+var _ Batcher = &Fake{}
+
+func NewFake() *Fake {
+	return &Fake{nodes: make(map[string]NodeKind), links: make(map[string]LinkDetails)}
+}
+
+func (f *Fake) record(call Call) {
+	f.calls = append(f.calls, call)
+}
+
+func (f *Fake) Init() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "Init"})
+	return nil
+}
+
+func (f *Fake) CreateNode(name string, kind NodeKind) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "CreateNode", Node: name})
+	if _, found := f.nodes[name]; found {
+		return "", fmt.Errorf("node %s already exists", name)
+	}
+	f.nodes[name] = kind
+	return name, nil
+}
+
+func (f *Fake) RemoveNode(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "RemoveNode", Node: name})
+	if _, found := f.nodes[name]; !found {
+		return fmt.Errorf("no node %s", name)
+	}
+	delete(f.nodes, name)
+	return nil
+}
+
+func (f *Fake) SetupLink(link LinkDetails) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "SetupLink", Link: link.NetworkName})
+	if _, found := f.links[link.NetworkName]; found {
+		return fmt.Errorf("link %s is already up", link.NetworkName)
+	}
+	for _, node := range []string{link.NodeOneId, link.NodeTwoId} {
+		if _, found := f.nodes[node]; !found {
+			return fmt.Errorf("link %s: no node %s", link.NetworkName, node)
+		}
+	}
+	f.links[link.NetworkName] = link
+	return nil
+}
+
+func (f *Fake) TearDownLink(link LinkDetails) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "TearDownLink", Link: link.NetworkName})
+	if _, found := f.links[link.NetworkName]; !found {
+		return fmt.Errorf("link %s is not up", link.NetworkName)
+	}
+	delete(f.links, link.NetworkName)
+	return nil
+}
+
+func (f *Fake) RunCommand(node string, command string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "RunCommand", Node: node, Command: command})
+	if _, found := f.nodes[node]; !found {
+		return fmt.Errorf("no node %s", node)
+	}
+	return nil
+}
+
+// RunBatch records the batch as one call, its commands are returned by Commands with the tool name in front
+func (f *Fake) RunBatch(node string, tool string, lines []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	commands := make([]string, len(lines))
+	for i, line := range lines {
+		commands[i] = tool + " " + line
+	}
+	f.record(Call{Method: "RunBatch", Node: node, Command: strings.Join(commands, "\n")})
+	if _, found := f.nodes[node]; !found {
+		return fmt.Errorf("no node %s", node)
+	}
+	return nil
+}
+
+func (f *Fake) Cleanup() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(Call{Method: "Cleanup"})
+	f.nodes = make(map[string]NodeKind)
+	f.links = make(map[string]LinkDetails)
+	return nil
+}
+
+// Calls returns a copy of the calls recorded so far, in the order they were made
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call{}, f.calls...)
+}
+
+// Commands returns the commands run in node, in the order they were run, including the commands of batches
+func (f *Fake) Commands(node string) (commands []string) {
+	for _, call := range f.Calls() {
+		if call.Node != node {
+			continue
+		}
+		switch call.Method {
+		case "RunCommand":
+			commands = append(commands, call.Command)
+		case "RunBatch":
+			commands = append(commands, strings.Split(call.Command, "\n")...)
+		}
+	}
+	return commands
+}
+
+// Execs returns the number of RunCommand and RunBatch calls made in node, each of which is one exec session in a container runtime
+func (f *Fake) Execs(node string) (execs int) {
+	for _, call := range f.Calls() {
+		if call.Node == node && (call.Method == "RunCommand" || call.Method == "RunBatch") {
+			execs++
+		}
+	}
+	return execs
+}
+
+// Links returns the links which are currently up
+func (f *Fake) Links() map[string]LinkDetails {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	links := make(map[string]LinkDetails, len(f.links))
+	for name, link := range f.links {
+		links[name] = link
+	}
+	return links
+}
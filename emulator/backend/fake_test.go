@@ -0,0 +1,47 @@
+package backend
+
+import "testing"
+
+func TestFakeRejectsInvalidCalls(t *testing.T) {
+	f := NewFake()
+	link := LinkDetails{NetworkName: "P7-Link-S1-S2", NodeOneId: "Sat1", NodeTwoId: "Sat2"}
+	if err := f.SetupLink(link); err == nil {
+		t.Error("link between missing nodes was set up")
+	}
+	f.CreateNode("Sat1", Satellite)
+	f.CreateNode("Sat2", Satellite)
+	if err := f.SetupLink(link); err != nil {
+		t.Error(err)
+	}
+	if err := f.SetupLink(link); err == nil {
+		t.Error("link was set up twice")
+	}
+	if err := f.TearDownLink(link); err != nil {
+		t.Error(err)
+	}
+	if err := f.TearDownLink(link); err == nil {
+		t.Error("link was torn down twice")
+	}
+	if err := f.RunCommand("Sat3", "ip a"); err == nil {
+		t.Error("command ran in missing node")
+	}
+	if len(f.Calls()) != 8 {
+		t.Errorf("expected 8 recorded calls, got %v", f.Calls())
+	}
+}
+
+func TestFakeRecordsBatches(t *testing.T) {
+	f := NewFake()
+	f.CreateNode("Sat1", Satellite)
+	f.RunCommand("Sat1", "ip a")
+	if err := f.RunBatch("Sat1", "tc", []string{"qdisc replace dev Sat2 root netem delay 7ms", "qdisc replace dev Sat3 root netem delay 3ms"}); err != nil {
+		t.Error(err)
+	}
+	commands := f.Commands("Sat1")
+	if len(commands) != 3 || commands[2] != "tc qdisc replace dev Sat3 root netem delay 3ms" {
+		t.Errorf("wrong commands %v", commands)
+	}
+	if f.Execs("Sat1") != 2 {
+		t.Errorf("expected 2 exec sessions, got %d", f.Execs("Sat1"))
+	}
+}
@@ -0,0 +1,154 @@
+// Package cache keeps propagated orbital data on disk, so repeated runs over the same TLEs do not propagate again
+package cache
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"project/space"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// version is bumped whenever the layout of the entries or of space.OrbitalData changes
+const version = 2
+
+// Cache stores one entry per key in Dir, an entry holds the first Steps steps of every satellite
+type Cache struct {
+	Dir string
+}
+
+type entry struct {
+	Version    int
+	Steps      int
+	Satellites []space.OrbitalData
+}
+
+// Key identifies propagated data by the TLE file contents, the propagation model, the start time and the step.
+// The duration is left out on purpose: a longer run extends the entry of a shorter one instead of starting over.
+func Key(tle []byte, model string, start time.Time, step time.Duration) string {
+	h := sha256.New()
+	h.Write(tle)
+	fmt.Fprintf(h, "\x00%s\x00%d\x00%d", model, start.UnixNano(), step)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".gob.gz")
+}
+
+// Load returns the cached satellites of key and the number of steps they hold, an error wrapping os.ErrNotExist if there are none
+func (c Cache) Load(key string) ([]space.OrbitalData, int, error) {
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading cache %s: %w", key, err)
+	}
+	var e entry
+	if err := gob.NewDecoder(zr).Decode(&e); err != nil {
+		return nil, 0, fmt.Errorf("reading cache %s: %w", key, err)
+	}
+	if e.Version != version {
+		return nil, 0, fmt.Errorf("cache %s has version %d, expected %d", key, e.Version, version)
+	}
+	return e.Satellites, e.Steps, nil
+}
+
+// Save stores the satellites under key, replacing the entry atomically so a crashed run never leaves half an entry
+func (c Cache) Save(key string, satdata []space.OrbitalData) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	steps := 0
+	if len(satdata) > 0 {
+		steps = len(satdata[0].Position)
+	}
+	f, err := os.CreateTemp(c.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	zw := gzip.NewWriter(f)
+	if err := gob.NewEncoder(zw).Encode(entry{Version: version, Steps: steps, Satellites: satdata}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+// Propagate returns steps steps of every satellite, taking what it can from the entry of key.
+// propagate(first, count) must return the steps first to first+count-1 of all satellites sorted by id;
+// it is only called for the steps the entry does not hold, after which the entry is extended.
+func (c Cache) Propagate(key string, steps int, propagate func(first, count int) []space.OrbitalData) ([]space.OrbitalData, error) {
+	cached, cachedSteps, err := c.Load(key)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("key", key).Msg("ignoring unreadable orbit cache")
+	}
+	if err != nil {
+		cached, cachedSteps = nil, 0
+	}
+	if cachedSteps >= steps {
+		log.Info().Str("key", key).Int("steps", steps).Int("cachedSteps", cachedSteps).Msg("orbital data from cache")
+		return truncate(cached, steps), nil
+	}
+
+	started := time.Now()
+	extension := propagate(cachedSteps, steps-cachedSteps)
+	satdata, ok := extend(cached, extension)
+	if !ok {
+		// the satellites changed although the key did not, start over
+		log.Warn().Str("key", key).Msg("cached satellites do not match, propagating all steps")
+		cachedSteps = 0
+		satdata = propagate(0, steps)
+	}
+	log.Info().Str("key", key).Int("cachedSteps", cachedSteps).Int("propagatedSteps", steps-cachedSteps).Dur("took", time.Since(started)).Msg("propagated orbital data")
+	return satdata, c.Save(key, satdata)
+}
+
+// truncate cuts every satellite to its first steps steps
+func truncate(satdata []space.OrbitalData, steps int) []space.OrbitalData {
+	for i := range satdata {
+		satdata[i].Position = satdata[i].Position[:steps]
+		satdata[i].Velocity = satdata[i].Velocity[:steps]
+		satdata[i].LatLong = satdata[i].LatLong[:steps]
+	}
+	return satdata
+}
+
+// extend appends the steps of extension to the satellites of cached, it reports false if the satellites differ
+func extend(cached, extension []space.OrbitalData) ([]space.OrbitalData, bool) {
+	if cached == nil {
+		return extension, true
+	}
+	if len(cached) != len(extension) {
+		return nil, false
+	}
+	for i := range cached {
+		if cached[i].SatelliteId != extension[i].SatelliteId {
+			return nil, false
+		}
+	}
+	for i := range cached {
+		cached[i].Position = append(cached[i].Position, extension[i].Position...)
+		cached[i].Velocity = append(cached[i].Velocity, extension[i].Velocity...)
+		cached[i].LatLong = append(cached[i].LatLong, extension[i].LatLong...)
+	}
+	return cached, true
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"project/space"
+	"testing"
+	"time"
+)
+
+// fakePropagation returns satellites whose position at step i is (id, i, 0) and records the calls
+func fakePropagation(ids []int, calls *[][2]int) func(first, count int) []space.OrbitalData {
+	return func(first, count int) []space.OrbitalData {
+		*calls = append(*calls, [2]int{first, count})
+		satdata := make([]space.OrbitalData, len(ids))
+		for i, id := range ids {
+			satdata[i] = space.OrbitalData{SatelliteId: id, Position: make([]space.Vector3, count), Velocity: make([]space.Vector3, count), LatLong: make([]space.LatLong, count)}
+			for step := 0; step < count; step++ {
+				satdata[i].Position[step] = space.Vector3{X: float64(id), Y: float64(first + step)}
+			}
+		}
+		return satdata
+	}
+}
+
+func checkSteps(t *testing.T, satdata []space.OrbitalData, steps int) {
+	t.Helper()
+	for _, sat := range satdata {
+		if len(sat.Position) != steps {
+			t.Fatalf("satellite %d has %d steps, expected %d", sat.SatelliteId, len(sat.Position), steps)
+		}
+		for step, position := range sat.Position {
+			if position != (space.Vector3{X: float64(sat.SatelliteId), Y: float64(step)}) {
+				t.Fatalf("satellite %d has position %v at step %d", sat.SatelliteId, position, step)
+			}
+		}
+	}
+}
+
+func TestPropagateReusesAndExtends(t *testing.T) {
+	c := Cache{Dir: filepath.Join(t.TempDir(), "orbits")}
+	key := Key([]byte("tle"), space.ModelSGP4, time.Date(2022, 9, 11, 12, 0, 0, 0, time.UTC), time.Second)
+	var calls [][2]int
+	propagate := fakePropagation([]int{3, 7}, &calls)
+
+	satdata, err := c.Propagate(key, 5, propagate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, satdata, 5)
+
+	satdata, err = c.Propagate(key, 3, propagate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, satdata, 3)
+
+	satdata, err = c.Propagate(key, 8, propagate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, satdata, 8)
+
+	if len(calls) != 2 || calls[0] != [2]int{0, 5} || calls[1] != [2]int{5, 3} {
+		t.Errorf("expected to propagate steps 0-4 and then 5-7 only, got %v", calls)
+	}
+	if _, steps, err := c.Load(key); err != nil || steps != 8 {
+		t.Errorf("expected the entry to hold 8 steps, got %d, %v", steps, err)
+	}
+}
+
+func TestPropagateStartsOverForOtherSatellites(t *testing.T) {
+	c := Cache{Dir: t.TempDir()}
+	var calls [][2]int
+	if _, err := c.Propagate("key", 2, fakePropagation([]int{1}, &calls)); err != nil {
+		t.Fatal(err)
+	}
+	satdata, err := c.Propagate("key", 4, fakePropagation([]int{1, 2}, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, satdata, 4)
+	if len(satdata) != 2 || calls[len(calls)-1] != [2]int{0, 4} {
+		t.Errorf("expected all steps of both satellites to be propagated again, got %d satellites and calls %v", len(satdata), calls)
+	}
+}
+
+func TestKey(t *testing.T) {
+	start := time.Date(2022, 9, 11, 12, 0, 0, 0, time.UTC)
+	key := Key([]byte("tle"), space.ModelSGP4, start, time.Second)
+	for _, other := range []string{
+		Key([]byte("tle2"), space.ModelSGP4, start, time.Second),
+		Key([]byte("tle"), space.ModelJ2, start, time.Second),
+		Key([]byte("tle"), space.ModelSGP4, start.Add(time.Second), time.Second),
+		Key([]byte("tle"), space.ModelSGP4, start, 2*time.Second),
+	} {
+		if other == key {
+			t.Error("different inputs share a key")
+		}
+	}
+	if Key([]byte("tle"), space.ModelSGP4, start.In(time.FixedZone("CET", 3600)), time.Second) != key {
+		t.Error("the key depends on the time zone of the start")
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, _, err := (Cache{Dir: t.TempDir()}).Load("missing"); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
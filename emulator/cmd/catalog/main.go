@@ -0,0 +1,53 @@
+// Command catalog imports bulk TLE and OMM downloads into an offline element set catalogue,
+// which answers the queries of Space-Track on machines without internet access:
+//
+//	go run ./cmd/catalog -dir ./catalog -group starlink starlink.txt snapshots/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"project/tle"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	dir := flag.String("dir", "./catalog", "Catalogue directory, created on the first import")
+	group := flag.String("group", "", "Group the imported snapshots belong to, such as starlink or oneweb | Default: no group")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file or directory...\nImports TLE and OMM files into the catalogue, a directory imports every file in it.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+
+	catalog, err := tle.OpenCatalog(*dir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", *dir).Msg("failed to open catalogue")
+	}
+	if flag.NArg() == 0 {
+		objects, sets := catalog.Objects()
+		log.Info().Str("dir", *dir).Int("objects", objects).Int("elementSets", sets).Str("groups", strings.Join(catalog.Groups(), ",")).Msg("catalogue")
+		return
+	}
+	for _, path := range flag.Args() {
+		files, err := tle.Files(path)
+		if err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("failed to read downloads")
+		}
+		for _, file := range files {
+			name, added, err := catalog.Import(file, *group)
+			if err != nil {
+				log.Fatal().Err(err).Str("file", file).Msg("failed to import")
+			}
+			log.Info().Str("file", file).Str("snapshot", name).Int("added", added).Msg("imported")
+		}
+	}
+	objects, sets := catalog.Objects()
+	log.Info().Str("dir", *dir).Int("objects", objects).Int("elementSets", sets).Msg("catalogue updated")
+}
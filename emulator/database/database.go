@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"project/space"
+	"project/walker"
 	"strconv"
 	"time"
 
@@ -163,17 +164,11 @@ func LoadSatellitePositions(fileName string, constellation string, startTime tim
 	row_count := pr.GetNumRows()
 	log.Printf("%d\n", row_count)
 
-	NumberOfSatellites := 0
-
-	if constellation == "Kepler" {
-		NumberOfSatellites = 140
-	} else if constellation == "OneWeb" {
-		NumberOfSatellites = 648
-	} else if constellation == "Starlink" {
-		NumberOfSatellites = 1584
-	} else {
-		log.Fatal("constellation was not correctly specified", constellation)
+	preset, err := walker.Preset(constellation)
+	if err != nil {
+		log.Fatal("constellation was not correctly specified ", err)
 	}
+	NumberOfSatellites := preset.Size()
 
 	expected_row_count := NumberOfSatellites * satelliteTimeSteps
 	if int(row_count) != expected_row_count { // TODO: Make a check that not only compares length but checks if entries are empty
@@ -190,6 +185,9 @@ func LoadSatellitePositions(fileName string, constellation string, startTime tim
 			Isactive:    true,
 			SatelliteId: sid,
 			Title:       strconv.Itoa(sid), // int to string
+			Shell:       -1,
+			Plane:       -1,
+			Slot:        -1,
 			Position:    make([]space.Vector3, satelliteTimeSteps),
 			Velocity:    make([]space.Vector3, satelliteTimeSteps),
 			LatLong:     make([]space.LatLong, satelliteTimeSteps),
@@ -221,6 +219,10 @@ func LoadSatellitePositions(fileName string, constellation string, startTime tim
 		}
 		tstart = tstart.Add(timestep)
 	}
+	// the positions file has no velocities
+	for i := range satdata {
+		satdata[i].DeriveVelocity(timestep)
+	}
 
 	return satdata
 }
@@ -268,6 +270,8 @@ func LoadGroundStationPositions(fileName string, startTime time.Time, timestep t
 			Position: make([]space.Vector3, groundstationTimeSteps),
 			IsAP:     true,
 			Isactive: false,
+			// satellite links use the distance between the positions, the elevation is only used without them
+			MinElevation: space.DefaultMinimumElevation,
 		}
 		gsdata[sid] = groundstationData
 	}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"project/backend"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+)
+
+// Backend runs every node as a docker container and every link as a docker network.
+// Docker has a single image for satellites and ground stations.
+type Backend struct {
+	ctx    context.Context
+	client *client.Client // connected by Init
+}
+
+var _ backend.Backend = (*Backend)(nil)
+var _ backend.Batcher = (*Backend)(nil)
+
+func (b *Backend) CreateNode(name string, kind backend.NodeKind) (string, error) {
+	if _, err := b.RunBackGroundContainer(name); err != nil {
+		return "", fmt.Errorf("failed to start %s container %s: %w", kind, name, err)
+	}
+	return name, nil
+}
+
+func (b *Backend) RemoveNode(name string) error {
+	timeoutTime := time.Millisecond * 1
+	if err := b.client.ContainerStop(b.ctx, name, &timeoutTime); err != nil {
+		return err
+	}
+	return b.client.ContainerRemove(b.ctx, name, types.ContainerRemoveOptions{})
+}
+
+// SetupLink connects two containers with a network of their own, the interface in each container is named after the other container
+func (b *Backend) SetupLink(details backend.LinkDetails) error {
+	networkId, err := b.createNetwork(details.NetworkName, details.Subnet)
+	if err != nil {
+		return err
+	}
+	if err := b.addContainerToNetwork(details.NodeOneId, networkId, details.NodeOneIP); err != nil {
+		return err
+	}
+	if err := b.addContainerToNetwork(details.NodeTwoId, networkId, details.NodeTwoIP); err != nil {
+		return err
+	}
+	if err := b.renameInterface(details.NodeOneId, details.NodeOneIP, details.NodeTwoId); err != nil {
+		return err
+	}
+	return b.renameInterface(details.NodeTwoId, details.NodeTwoIP, details.NodeOneId)
+}
+
+// TearDownLink disconnects both containers from the network of the link and removes it
+func (b *Backend) TearDownLink(details backend.LinkDetails) error {
+	for _, containerId := range []string{details.NodeOneId, details.NodeTwoId} {
+		if err := b.client.NetworkDisconnect(b.ctx, details.NetworkName, containerId, true); err != nil {
+			log.Error().Err(err).Str("container", containerId).Str("network", details.NetworkName).Msg("Failed to disconnect container from network")
+			return err
+		}
+	}
+	return b.client.NetworkRemove(b.ctx, details.NetworkName)
+}
+
+// Cleanup removes the satellite containers and the networks of the links
+func (b *Backend) Cleanup() error {
+	log.Info().Msg("Cleaning up")
+	containers, err := b.client.ContainerList(b.ctx, types.ContainerListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, container := range containers {
+		if container.Image == imageName {
+			log.Info().Str("ID", container.ID[:10]).Msg("Stopping container")
+
+			timeoutTime := time.Millisecond * 1
+			if err := b.client.ContainerStop(b.ctx, container.ID, &timeoutTime); err != nil {
+				return err
+			}
+			log.Info().Str("ID", container.ID[:10]).Msg("Removing container")
+
+			if err := b.client.ContainerRemove(b.ctx, container.ID, types.ContainerRemoveOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	networks, err := b.client.NetworkList(b.ctx, types.NetworkListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, network := range networks {
+		if strings.Contains(network.Name, "P7") {
+			log.Info().Str("ID", network.ID).Msg("Removing network")
+			if err := b.client.NetworkRemove(b.ctx, network.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -1,50 +1,48 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"project/backend"
 	"strings"
-	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/rs/zerolog/log"
 )
 
 var (
-	c         *client.Client
 	imageName = "satellite:latest"
 )
 
-func InstantiateDocker() {
+// Init connects to the docker daemon of the environment
+func (b *Backend) Init() error {
 	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	c = cli
 	if err != nil {
-		panic(err)
+		return err
 	}
-	_, err = c.Info(ctx)
-	if err != nil {
-		panic(err)
+	if _, err := cli.Info(ctx); err != nil {
+		return err
 	}
+	b.ctx, b.client = ctx, cli
 	// out, err := c.ImagePull(ctx, imageName, types.ImagePullOptions{})
 	// if err != nil {
 	// 	panic(err)
 	// }
 	// defer out.Close()
 	// io.Copy(os.Stdout, out)
+	return nil
 }
 
-func RunBackGroundContainer(containerName string) string {
-	ctx := context.Background()
-	// c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	// if err != nil {
-	// 	panic(err)
-	// }
+func (b *Backend) RunBackGroundContainer(containerName string) (string, error) {
 	log.Info().Str("name", containerName).Msg("Creating container")
-	resp, err := c.ContainerCreate(ctx, &container.Config{
+	resp, err := b.client.ContainerCreate(b.ctx, &container.Config{
 		Image:    imageName,
 		Hostname: containerName,
 	},
@@ -53,30 +51,20 @@ func RunBackGroundContainer(containerName string) string {
 			CapAdd:     strslice.StrSlice([]string{"NET_ADMIN"})},
 		nil, nil, containerName)
 	if err != nil {
-		log.Error().Err(err)
-		return ""
+		return "", err
 	}
-	if err := c.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		log.Error().Err(err)
-		return ""
+	if err := b.client.ContainerStart(b.ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return "", err
 	}
 
 	log.Info().Str("ID", resp.ID).Msg("Started container with ID")
-	return resp.ID
-}
-
-type linkDetails struct {
-	NetworkName string `parquet:"network_name"`
-	Subnet      string `parquet:"subnet"`
-	NodeOneId   string `parquet:"node_one_id"`
-	NodeOneIP   string `parquet:"node_one_ip"`
-	NodeTwoId   string `parquet:"node_two_id"`
-	NodeTwoIP   string `parquet:"node_two_ip"`
+	return resp.ID, nil
 }
 
-func createNetwork(name string, subnet string) string {
-	ctx := context.Background()
+// LinkDetails describes a link, the same as in the podman package
+type LinkDetails = backend.LinkDetails
 
+func (b *Backend) createNetwork(name string, subnet string) (string, error) {
 	newnetwork := types.NetworkCreate{IPAM: &network.IPAM{
 		Driver: "default",
 		Config: []network.IPAMConfig{{
@@ -84,86 +72,69 @@ func createNetwork(name string, subnet string) string {
 		}},
 	}}
 
-	res, err := c.NetworkCreate(ctx, name, newnetwork)
+	res, err := b.client.NetworkCreate(b.ctx, name, newnetwork)
 	if err != nil {
-		log.Error().Err(err)
-		return ""
+		log.Error().Err(err).Str("name", name).Msg("Failed to create network")
+		return "", err
 	}
-	return res.ID
+	return res.ID, nil
 }
 
-func addContainerToNetwork(containerId string, networkId string, IPAddress string) {
-	ctx := context.Background()
+func (b *Backend) addContainerToNetwork(containerId string, networkId string, IPAddress string) error {
 	networkConf := network.EndpointSettings{
-		IPAddress: IPAddress,
+		IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: IPAddress},
 	}
-	c.NetworkConnect(ctx, networkId, containerId, &networkConf)
+	return b.client.NetworkConnect(b.ctx, networkId, containerId, &networkConf)
 }
 
-func CreateLink(details linkDetails) {
-	networkId := createNetwork(details.NetworkName, details.Subnet)
-
-	addContainerToNetwork(details.NodeOneId, networkId, details.NodeOneIP)
-	addContainerToNetwork(details.NodeTwoId, networkId, details.NodeTwoIP)
+// renameInterface gives the interface holding IPAddress the name ifname.
+// Docker can not choose the interface name when connecting a network, so it is renamed afterwards.
+func (b *Backend) renameInterface(containerId string, IPAddress string, ifname string) error {
+	script := fmt.Sprintf(`dev=$(ip -o -4 addr show | awk '$4 ~ /^%s\// {print $2}') && ip link set "$dev" down && ip link set "$dev" name %s && ip link set %s up`, IPAddress, ifname, ifname)
+	return b.runCommand(containerId, []string{"sh", "-c", script})
 }
 
-func RunCommand(nodeID string, command string) error {
-	ctx := context.Background()
-	cmd := strings.Split(command, " ")
+// runCommand runs cmd in the container and waits for it to exit, a non zero exit code is an error
+func (b *Backend) runCommand(nodeID string, cmd []string) error {
 	log.Info().Str("node", nodeID).Strs("command", cmd).Msg("Running command")
-	execId, err := c.ContainerExecCreate(ctx, nodeID, types.ExecConfig{
-		Privileged: true,
-		User:       "root",
-		Cmd:        cmd,
+	execId, err := b.client.ContainerExecCreate(b.ctx, nodeID, types.ExecConfig{
+		Privileged:   true,
+		User:         "root",
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          cmd,
 	})
 	if err != nil {
 		log.Error().Err(err)
 		return err
 	}
-	err = c.ContainerExecStart(ctx, execId.ID, types.ExecStartCheck{})
+	attached, err := b.client.ContainerExecAttach(b.ctx, execId.ID, types.ExecStartCheck{})
 	if err != nil {
 		log.Error().Err(err)
 		return err
 	}
-	return nil
-}
-
-func Cleanup() {
-	ctx := context.Background()
-	log.Info().Msg("Cleaning up")
-	containers, err := c.ContainerList(ctx, types.ContainerListOptions{})
-	if err != nil {
-		panic(err)
-	}
-
-	for _, container := range containers {
-		if container.Image == imageName {
-			log.Info().Str("ID", container.ID[:10]).Msg("Stopping container")
-
-			timeoutTime := time.Millisecond * 1
-			if err := c.ContainerStop(ctx, container.ID, &timeoutTime); err != nil {
-				panic(err)
-			}
-			log.Info().Str("ID", container.ID[:10]).Msg("Removing container")
-
-			if err := c.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{}); err != nil {
-				panic(err)
-			}
-		}
+	defer attached.Close()
+	// the output ends when the command exits
+	var out bytes.Buffer
+	if _, err := stdcopy.StdCopy(&out, &out, attached.Reader); err != nil {
+		return err
 	}
-
-	networks, err := c.NetworkList(ctx, types.NetworkListOptions{})
+	inspect, err := b.client.ContainerExecInspect(b.ctx, execId.ID)
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	for _, network := range networks {
-		if strings.Contains(network.Name, "P7") {
-			log.Info().Str("ID", network.ID).Msg("Removing network")
-			if err := c.NetworkRemove(ctx, network.ID); err != nil {
-				panic(err)
-			}
-		}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("%s in %s exited with %d: %s", cmd[0], nodeID, inspect.ExitCode, strings.TrimSpace(out.String()))
 	}
+	return nil
+}
+
+// RunCommand runs command, split at spaces, in the container
+func (b *Backend) RunCommand(nodeID string, command string) error {
+	return b.runCommand(nodeID, strings.Split(command, " "))
+}
 
+// RunBatch runs all lines with one "tool -batch" exec session in the container
+func (b *Backend) RunBatch(nodeID string, tool string, lines []string) error {
+	return b.runCommand(nodeID, backend.BatchScript(tool, lines))
 }
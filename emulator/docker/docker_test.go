@@ -5,23 +5,45 @@ import (
 )
 
 func TestRunContainer(t *testing.T) {
-	InstantiateDocker()
-	id := RunBackGroundContainer("P7-testing")
+	b := &Backend{}
+	if err := b.Init(); err != nil {
+		t.Fatal(err)
+	}
+	id, err := b.RunBackGroundContainer("P7-testing")
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Log(id)
-	RunCommand(id, "ip route add 172.17.0.3 via 172.17.0.1")
-	RunCommand(id, "touch /root/P7.txt")
-	Cleanup()
+	b.RunCommand(id, "ip route add 172.17.0.3 via 172.17.0.1")
+	b.RunCommand(id, "touch /root/P7.txt")
+	b.Cleanup()
 }
 
 func TestAddLink(t *testing.T) {
 
-	InstantiateDocker()
-	nodeOneID := RunBackGroundContainer("P7-1-Test")
-	nodeTwoID := RunBackGroundContainer("P7-2-Test")
-	nodeThreeID := RunBackGroundContainer("P7-3-Test")
-	nodeFourID := RunBackGroundContainer("P7-4-Test")
+	b := &Backend{}
+	if err := b.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup()
+	nodeOneID, err := b.RunBackGroundContainer("P7-1-Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeTwoID, err := b.RunBackGroundContainer("P7-2-Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeThreeID, err := b.RunBackGroundContainer("P7-3-Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeFourID, err := b.RunBackGroundContainer("P7-4-Test")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	link1 := linkDetails{
+	link1 := LinkDetails{
 		NodeOneId:   nodeOneID,
 		NodeTwoId:   nodeTwoID,
 		NetworkName: "P7-link-1",
@@ -30,7 +52,7 @@ func TestAddLink(t *testing.T) {
 		NodeTwoIP:   "192.168.0.3",
 	}
 
-	link2 := linkDetails{
+	link2 := LinkDetails{
 		NodeOneId:   nodeTwoID,
 		NodeTwoId:   nodeThreeID,
 		NetworkName: "P7-link-2",
@@ -39,7 +61,7 @@ func TestAddLink(t *testing.T) {
 		NodeTwoIP:   "192.168.1.3",
 	}
 
-	link3 := linkDetails{
+	link3 := LinkDetails{
 		NodeOneId:   nodeThreeID,
 		NodeTwoId:   nodeFourID,
 		NetworkName: "P7-link-3",
@@ -48,9 +70,13 @@ func TestAddLink(t *testing.T) {
 		NodeTwoIP:   "192.168.2.3",
 	}
 
-	CreateLink(link1)
-	CreateLink(link2)
-	CreateLink(link3)
-
-	Cleanup()
+	if err := b.SetupLink(link1); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetupLink(link2); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetupLink(link3); err != nil {
+		t.Fatal(err)
+	}
 }
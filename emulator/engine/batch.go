@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"fmt"
+	"project/backend"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultConcurrency is the number of nodes commands are run in at the same time if the scenario does not set it
+const DefaultConcurrency = 32
+
+// batch collects the netem and route commands of one update per node, so that a node gets
+// one "tc -batch" and one "ip -batch" exec session instead of one exec session per command
+type batch struct {
+	mu       sync.Mutex
+	commands map[string][]string
+	order    []string // nodes in the order they got their first command
+}
+
+func newBatch() *batch {
+	return &batch{commands: make(map[string][]string)}
+}
+
+func (b *batch) add(node string, commands ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, found := b.commands[node]; !found {
+		b.order = append(b.order, node)
+	}
+	b.commands[node] = append(b.commands[node], commands...)
+}
+
+func (b *batch) len() (count int) {
+	for _, commands := range b.commands {
+		count += len(commands)
+	}
+	return count
+}
+
+// commandGroups splits commands into runs of consecutive commands of the same tool, keeping their order
+func commandGroups(commands []string) (tools []string, groups [][]string) {
+	for _, command := range commands {
+		tool, _, _ := strings.Cut(command, " ")
+		if len(tools) == 0 || tools[len(tools)-1] != tool {
+			tools = append(tools, tool)
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], command)
+	}
+	return tools, groups
+}
+
+// runNode runs the commands of one node, batching ip and tc commands if the backend can
+func (e *Engine) runNode(node string, commands []string) {
+	batcher, canBatch := e.backend.(backend.Batcher)
+	tools, groups := commandGroups(commands)
+	for i, group := range groups {
+		if !canBatch || len(group) == 1 || !slices.Contains(backend.BatchTools, tools[i]) {
+			for _, command := range group {
+				e.runCommand(node, command)
+			}
+			continue
+		}
+		lines := make([]string, len(group))
+		for j, command := range group {
+			lines[j] = strings.TrimPrefix(command, tools[i]+" ")
+		}
+		if err := batcher.RunBatch(node, tools[i], lines); err != nil {
+			log.Error().Err(err).Str("node", node).Str("tool", tools[i]).Int("commands", len(lines)).Msg("batch failed")
+		}
+	}
+}
+
+func (e *Engine) concurrency() int {
+	if e.scenario.Execution.Concurrency > 0 {
+		return e.scenario.Execution.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+// forEachLink calls action for every link with at most Execution.Concurrency calls at a time
+func (e *Engine) forEachLink(links []string, failure string, action func(backend.LinkDetails) error) {
+	slots := make(chan struct{}, e.concurrency())
+	wg := sync.WaitGroup{}
+	for _, link := range links {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(linkDetails backend.LinkDetails) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := action(linkDetails); err != nil {
+				log.Error().Err(err).Str("link", linkDetails.NetworkName).Msg(failure)
+			}
+		}(e.links[link])
+	}
+	wg.Wait()
+}
+
+// runBatch runs the commands of the batch with at most Execution.Concurrency nodes at a time.
+// The time every node took is written to the command timing file, the slowest node is logged.
+func (e *Engine) runBatch(index int, b *batch) {
+	if len(b.order) == 0 {
+		return
+	}
+	started := time.Now()
+	took := make([]time.Duration, len(b.order))
+	slots := make(chan struct{}, e.concurrency())
+	wg := sync.WaitGroup{}
+	for i, node := range b.order {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, node string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			nodeStarted := time.Now()
+			e.runNode(node, b.commands[node])
+			took[i] = time.Since(nodeStarted)
+		}(i, node)
+	}
+	wg.Wait()
+
+	nodes := append([]string{}, b.order...)
+	durations := make(map[string]time.Duration, len(nodes))
+	slowest := 0
+	for i, node := range b.order {
+		durations[node] = took[i]
+		if took[i] > took[slowest] {
+			slowest = i
+		}
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		// step, node, number of commands, microseconds
+		fmt.Fprintf(e.commandTiming, "%d\t%s\t%d\t%d\n", index, node, len(b.commands[node]), durations[node].Microseconds())
+	}
+	log.Info().Int("index", index).Int("nodes", len(nodes)).Int("commands", b.len()).Str("slowestNode", b.order[slowest]).
+		Dur("slowest", took[slowest]).Dur("took", time.Since(started)).Msg("commands applied")
+}
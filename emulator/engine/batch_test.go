@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandGroups(t *testing.T) {
+	tools, groups := commandGroups([]string{"tc qdisc replace dev Sat2", "tc qdisc replace dev Sat3", "ip route replace 1.2.3.4 via 1.2.3.5", "tc qdisc replace dev Sat4"})
+	if strings.Join(tools, " ") != "tc ip tc" {
+		t.Errorf("wrong tools %v", tools)
+	}
+	if len(groups) != 3 || len(groups[0]) != 2 || groups[2][0] != "tc qdisc replace dev Sat4" {
+		t.Errorf("wrong groups %v", groups)
+	}
+}
+
+func TestStepBatchesCommandsPerNode(t *testing.T) {
+	e, fake := testEngine(t, &Periodic{Interval: 1})
+	e.Step(0)
+	// two netem commands in one tc batch and the forward and reverse route in one ip batch
+	if len(fake.Commands("Sat1")) != 4 || fake.Execs("Sat1") != 2 {
+		t.Errorf("expected 4 commands in 2 exec sessions, got %d: %v", fake.Execs("Sat1"), fake.Commands("Sat1"))
+	}
+	raw, err := os.ReadFile(filepath.Join(e.runDir, "command-timing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "0\tSat1\t4\t") {
+		t.Errorf("expected the timing of Sat1 at step 0, got %q", raw)
+	}
+}
@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"project/backend"
+	"project/scenario"
+	"project/space"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// timeline actions, in the order the engine performs them within a step
+const (
+	actionNode     = "node"
+	actionLinkUp   = "link-up"
+	actionNetem    = "netem"
+	actionRoute    = "route"
+	actionLinkDown = "link-down"
+	actionExec     = "exec"
+)
+
+var actionOrder = map[string]int{actionNode: 0, actionLinkUp: 1, actionNetem: 2, actionRoute: 3, actionLinkDown: 4, actionExec: 5}
+
+type event struct {
+	action string
+	node   string
+	detail string
+}
+
+// timeline is a Backend that writes every call as a line of the dry run timeline instead of acting on it:
+// "<simulation time>\t<step>\t<action>\t<node or link>\t<details>".
+// The engine issues the calls of a step concurrently, so the events of a step are sorted before they are written.
+type timeline struct {
+	mu     sync.Mutex
+	w      io.Writer
+	index  int
+	now    time.Time
+	events []event
+	counts map[string]int
+	err    error
+}
+
+var _ backend.Backend = &timeline{}
+var _ backend.Batcher = &timeline{}
+
+func newTimeline(w io.Writer, start time.Time) *timeline {
+	return &timeline{w: w, now: start, counts: make(map[string]int)}
+}
+
+func (t *timeline) add(action, node, detail string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event{action, node, detail})
+	t.counts[action]++
+}
+
+// setStep writes the events of the previous step and moves the clock to step index
+func (t *timeline) setStep(index int, now time.Time) {
+	t.flush()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.index = index
+	t.now = now
+}
+
+func (t *timeline) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sort.SliceStable(t.events, func(i, j int) bool {
+		a, b := t.events[i], t.events[j]
+		if a.action != b.action {
+			return actionOrder[a.action] < actionOrder[b.action]
+		}
+		if a.node != b.node {
+			return a.node < b.node
+		}
+		return a.detail < b.detail
+	})
+	for _, e := range t.events {
+		if t.err != nil {
+			break
+		}
+		_, t.err = fmt.Fprintf(t.w, "%s\t%d\t%s\t%s\t%s\n", t.now.Format(time.RFC3339Nano), t.index, e.action, e.node, e.detail)
+	}
+	t.events = t.events[:0]
+}
+
+func (t *timeline) Init() error    { return nil }
+func (t *timeline) Cleanup() error { return nil }
+
+func (t *timeline) CreateNode(name string, kind backend.NodeKind) (string, error) {
+	t.add(actionNode, name, kind.String())
+	return name, nil
+}
+
+func (t *timeline) RemoveNode(name string) error {
+	t.add(actionNode, name, "remove")
+	return nil
+}
+
+func (t *timeline) SetupLink(link backend.LinkDetails) error {
+	t.add(actionLinkUp, link.NetworkName, fmt.Sprintf("%s %s - %s %s", link.NodeOneId, link.NodeOneIP, link.NodeTwoId, link.NodeTwoIP))
+	return nil
+}
+
+func (t *timeline) TearDownLink(link backend.LinkDetails) error {
+	t.add(actionLinkDown, link.NetworkName, link.NodeOneId+" - "+link.NodeTwoId)
+	return nil
+}
+
+func (t *timeline) RunCommand(node string, command string) error {
+	switch {
+	case strings.HasPrefix(command, "tc qdisc"):
+		t.add(actionNetem, node, command)
+	case strings.HasPrefix(command, "ip route"):
+		t.add(actionRoute, node, command)
+	default:
+		t.add(actionExec, node, command)
+	}
+	return nil
+}
+
+// RunBatch writes every line of the batch as its own event
+func (t *timeline) RunBatch(node string, tool string, lines []string) error {
+	for _, line := range lines {
+		t.RunCommand(node, tool+" "+line)
+	}
+	return nil
+}
+
+// DryRun runs the step loop of the scenario as fast as possible without a runtime.
+// Every planned action is written to w with its simulation time, the route change and route cost files are written to runDir as in a real run.
+func DryRun(sc scenario.Scenario, policy Policy, orbits space.Provider, gsdata []space.GroundStation, runDir string, w io.Writer) error {
+	t := newTimeline(w, sc.Time.Start)
+	e, err := New(sc, policy, t, orbits, gsdata, runDir)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	if err := e.Start(); err != nil {
+		return err
+	}
+
+	timeStep := time.Duration(sc.Time.Step)
+	started := time.Now()
+	for index := 0; index < sc.Steps()-1; index++ {
+		t.setStep(index, sc.Time.Start.Add(time.Duration(index)*timeStep))
+		e.Step(index)
+		e.commands.Wait()
+		for update := 1; update < sc.NetemUpdates(); update++ {
+			offset := time.Duration(update) * time.Duration(sc.Policy.NetemInterval)
+			t.setStep(index, sc.Time.Start.Add(time.Duration(index)*timeStep+offset))
+			e.refreshNetem(index, offset)
+		}
+	}
+	t.flush()
+	log.Info().Dur("took", time.Since(started)).Int("steps", sc.Steps()-1).Int("pathChanges", e.PathChanges()).
+		Int("linkUps", t.counts[actionLinkUp]).Int("linkDowns", t.counts[actionLinkDown]).
+		Int("netemChanges", t.counts[actionNetem]).Int("routeChanges", t.counts[actionRoute]).Msg("dry run done")
+	return t.err
+}
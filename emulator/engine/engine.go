@@ -0,0 +1,668 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"project/backend"
+	"project/graph"
+	"project/linkset"
+	"project/routing"
+	"project/scenario"
+	"project/space"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	yourbasic "github.com/yourbasic/graph"
+	"golang.org/x/exp/slices"
+)
+
+// Engine runs the emulation loop: it keeps the graph of the constellation up to date,
+// asks its Policy when to recompute the paths and applies links, routes and netem delays to the containers.
+// Every connection of the scenario is a flow with its own path, links and route tables.
+// With network routing the route tables of the flows are replaced by forwarding tables in every node.
+type Engine struct {
+	scenario    scenario.Scenario
+	policy      Policy
+	backend     backend.Backend
+	orbits      space.Provider
+	ephemeris   space.Ephemeris     // orbits between the steps
+	satellites  []space.OrbitalData // without positions, graph id is the index
+	gsdata      []space.GroundStation
+	connections []Connection
+	runDir      string
+
+	containers []string
+	links      map[string]backend.LinkDetails
+	graph      *yourbasic.Mutable
+	graphSize  int
+	topology   graph.SatelliteTopology
+	limits     graph.LinkLimits
+
+	flows        []*flow
+	linkRefs     linkRefs
+	prefixes     []routing.Prefix         // ground station prefixes, network routing only
+	forwarding   routing.ForwardingTables // tables installed in the nodes, network routing only
+	networkLinks []string                 // links used by the forwarding tables
+	pathChanges  int
+	lastNetem    int
+	commands     sync.WaitGroup // scenario commands still running
+
+	routeChanges  *os.File
+	routeCosts    *os.File
+	commandTiming *os.File
+	linkMotion    *os.File
+	illumination  *os.File
+	illuminated   []space.Illumination // of every satellite at the last step written to illumination
+}
+
+// New prepares an engine for the scenario, the positions of the satellites are read from orbits step by step
+func New(sc scenario.Scenario, policy Policy, b backend.Backend, orbits space.Provider, gsdata []space.GroundStation, runDir string) (*Engine, error) {
+	connections, err := Connections(gsdata, sc.Connections)
+	if err != nil {
+		return nil, err
+	}
+	satellites := orbits.Satellites()
+	limits := linkLimits(sc, orbits.Start())
+	e := &Engine{
+		scenario:    sc,
+		policy:      policy,
+		backend:     b,
+		orbits:      orbits,
+		ephemeris:   space.Ephemeris{Orbits: orbits, Start: orbits.Start(), Step: time.Duration(sc.Time.Step)},
+		satellites:  satellites,
+		gsdata:      gsdata,
+		connections: connections,
+		runDir:      runDir,
+		graphSize:   len(satellites) + len(gsdata),
+		topology:    newTopology(sc.Links, limits, satellites),
+		limits:      limits,
+		linkRefs:    make(linkRefs),
+		lastNetem:   -1,
+	}
+	for _, connection := range connections {
+		e.flows = append(e.flows, &flow{
+			connection: connection,
+			name:       gsdata[connection.Source].Title + "-" + gsdata[connection.Destination].Title,
+		})
+	}
+	e.routeChanges, err = os.Create(filepath.Join(runDir, "route-changes"))
+	if err != nil {
+		return nil, err
+	}
+	e.routeCosts, err = os.Create(filepath.Join(runDir, "route-cost"))
+	if err != nil {
+		e.routeChanges.Close()
+		return nil, err
+	}
+	e.commandTiming, err = os.Create(filepath.Join(runDir, "command-timing"))
+	if err != nil {
+		e.routeChanges.Close()
+		e.routeCosts.Close()
+		return nil, err
+	}
+	e.linkMotion, err = os.Create(filepath.Join(runDir, "link-motion"))
+	if err != nil {
+		e.routeChanges.Close()
+		e.routeCosts.Close()
+		e.commandTiming.Close()
+		return nil, err
+	}
+	e.illumination, err = os.Create(filepath.Join(runDir, "illumination"))
+	if err != nil {
+		e.routeChanges.Close()
+		e.routeCosts.Close()
+		e.commandTiming.Close()
+		e.linkMotion.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// Start creates a container for every satellite and ground station and prepares the link map and graph
+func (e *Engine) Start() error {
+	if err := e.backend.Init(); err != nil {
+		return err
+	}
+	if err := e.backend.Cleanup(); err != nil {
+		return err
+	}
+
+	e.containers = make([]string, e.graphSize)
+	// a goroutine is launched for each container creation
+	wg := sync.WaitGroup{}
+	for i, sat := range e.satellites {
+		wg.Add(1)
+		go func(index int, id int) {
+			defer wg.Done()
+			e.containers[index] = e.createNode("Sat"+strconv.Itoa(id), backend.Satellite)
+		}(i, sat.SatelliteId)
+	}
+	for i, gs := range e.gsdata {
+		wg.Add(1)
+		go func(index int, title string) {
+			defer wg.Done()
+			e.containers[index] = e.createNode("GS"+title, backend.GroundStation)
+		}(len(e.satellites)+i, gs.Title)
+	}
+	wg.Wait()
+
+	e.links = SetupLinkMap(e.containers, e.satellites, e.gsdata)
+	routing.LINKS = e.links
+	log.Info().Int("links", len(e.links)).Msg("created links")
+
+	e.graph = graph.InstantiateGraph(e.graphSize)
+	graph.SetupGraphAccessPointEdges(e.graph, e.graphSize, e.gsdata, e.scenario.Links.AccessPointRange)
+	log.Info().Float64("accessPointRange", e.scenario.Links.AccessPointRange).Msg("graphAccessPointEdges")
+	if e.scenario.NetworkRouting() {
+		e.prefixes = e.groundStationPrefixes()
+		log.Info().Int("prefixes", len(e.prefixes)).Msg("network routing")
+	}
+	return nil
+}
+
+// groundStationPrefixes returns the subnets of the links between access points and the user equipment in their range
+func (e *Engine) groundStationPrefixes() (prefixes []routing.Prefix) {
+	for i, ap := range e.gsdata {
+		if !ap.IsAP {
+			continue
+		}
+		for j, ue := range e.gsdata {
+			if ue.IsAP {
+				continue
+			}
+			node, peer := len(e.satellites)+i, len(e.satellites)+j
+			if !e.graph.Edge(node, peer) || e.graph.Cost(node, peer) < 0 {
+				continue
+			}
+			prefixes = append(prefixes, routing.Prefix{Subnet: e.links[LinkName(node, peer)].Subnet, Node: node, Peer: peer})
+		}
+	}
+	return prefixes
+}
+
+func (e *Engine) createNode(name string, kind backend.NodeKind) string {
+	node, err := e.backend.CreateNode(name, kind)
+	if err != nil {
+		log.Error().Err(err).Str("name", name).Msg("Failed to create node")
+		return name
+	}
+	return node
+}
+
+// Close removes all containers and networks and closes the output files
+func (e *Engine) Close() {
+	if err := e.backend.Cleanup(); err != nil {
+		log.Error().Err(err).Msg("cleanup failed")
+	}
+	e.routeChanges.Close()
+	e.routeCosts.Close()
+	e.commandTiming.Close()
+	e.linkMotion.Close()
+	e.illumination.Close()
+}
+
+// Run steps through the scenario in real time until the end of the scenario or until stop receives a signal
+func (e *Engine) Run(stop <-chan os.Signal) {
+	timeStep := time.Duration(e.scenario.Time.Step)
+	simulationStart := time.Now()
+	log.Info().Time("simulationStart", simulationStart).Str("policy", e.policy.Name()).Msg("starting simulation")
+
+	for index := 0; index < e.scenario.Steps()-1; index++ {
+		select {
+		case stopsignal := <-stop:
+			log.Info().Interface("signal", stopsignal).Msg("shutting down")
+			time.Sleep(2 * time.Second)
+			return
+		default:
+		}
+
+		e.Step(index)
+
+		//Wait until next iteration based on time.
+		targetTime := simulationStart.Add(time.Duration(index) * timeStep)
+		// with a netem interval shorter than the step the delays follow the satellites until the next step
+		for update := 1; update < e.scenario.NetemUpdates(); update++ {
+			offset := time.Duration(update) * time.Duration(e.scenario.Policy.NetemInterval)
+			time.Sleep(time.Until(targetTime.Add(offset - timeStep)))
+			e.refreshNetem(index, offset)
+		}
+		tooSlow := time.Now().After(targetTime)
+		if tooSlow {
+			log.Warn().Bool("computerIsPotato", tooSlow).Time("targetTime", targetTime).Dur("duration", time.Since(targetTime)).Msg("simulation not running in real time")
+		}
+		time.Sleep(time.Until(targetTime))
+	}
+}
+
+// Step performs the route (L3) and netem (L2) updates due at step index
+func (e *Engine) Step(index int) {
+	if e.policy.UpdateRoutes(index, e) {
+		e.updateRoutes(index)
+	}
+	if index%e.scenario.L2Steps() == 0 && e.lastNetem != index {
+		b := newBatch()
+		e.updateNetem(index, b)
+		e.runBatch(index, b)
+	}
+	e.runCommands(index)
+	e.writeIllumination(index)
+}
+
+// Path returns the graph ids of the current path of the first connection
+func (e *Engine) Path() []int {
+	if len(e.flows) == 0 {
+		return nil
+	}
+	return e.flows[0].path
+}
+
+// Paths returns the current path of every connection, in the order of the scenario
+func (e *Engine) Paths() (paths [][]int) {
+	for _, f := range e.flows {
+		paths = append(paths, f.path)
+	}
+	return paths
+}
+
+// PathChanges returns the number of path changes applied so far, summed over all flows
+func (e *Engine) PathChanges() int {
+	return e.pathChanges
+}
+
+func (e *Engine) isGroundStation(graphid int) bool {
+	return graphid >= len(e.satellites)
+}
+
+// linkDistance returns the distance in km between two graph nodes at step index and whether they are in range of each other.
+// Links between a ground station and a satellite use the ground station positions if they are known.
+func (e *Engine) linkDistance(index, graphid_1, graphid_2 int) (distance float64, reachable bool) {
+	groundPosition := func(gs space.GroundStation) (space.Vector3, bool) {
+		if index < len(gs.Position) {
+			return gs.Position[index], true
+		}
+		return space.Vector3{}, false
+	}
+	return e.nodeDistance(e.orbits.At(index), groundPosition, graphid_1, graphid_2)
+}
+
+// DistanceAt returns the distance in km between two graph nodes at any time t within the run and whether they are in range of each other,
+// so delays and reachability can be checked more often than the orbital data has steps. Between two steps the satellites are
+// interpolated by the ephemeris and known ground station positions follow the straight line between the steps.
+func (e *Engine) DistanceAt(t time.Time, graphid_1, graphid_2 int) (distance float64, reachable bool, err error) {
+	index, fraction, err := e.ephemeris.Locate(t)
+	if err != nil {
+		return 0, false, err
+	}
+	if fraction == 0 {
+		distance, reachable = e.linkDistance(index, graphid_1, graphid_2)
+		return distance, reachable, nil
+	}
+	states, err := e.ephemeris.StatesAt(t)
+	if err != nil {
+		return 0, false, err
+	}
+	groundPosition := func(gs space.GroundStation) (space.Vector3, bool) {
+		if index+1 < len(gs.Position) {
+			return space.Lerp(gs.Position[index], gs.Position[index+1], fraction), true
+		}
+		return space.Vector3{}, false
+	}
+	distance, reachable = e.nodeDistance(states, groundPosition, graphid_1, graphid_2)
+	return distance, reachable, nil
+}
+
+// nodeDistance returns the distance between two graph nodes with the satellites at states,
+// groundPosition gives the position of a ground station if it is known
+func (e *Engine) nodeDistance(states []space.State, groundPosition func(space.GroundStation) (space.Vector3, bool), graphid_1, graphid_2 int) (distance float64, reachable bool) {
+	maxFSODistance := e.scenario.Links.MaxFSODistance
+	if e.isGroundStation(graphid_1) {
+		graphid_1, graphid_2 = graphid_2, graphid_1
+	}
+	if e.isGroundStation(graphid_1) { // both are ground stations
+		return 0, true
+	}
+	sat := states[graphid_1]
+	if !e.isGroundStation(graphid_2) {
+		distance = sat.Position.Distance(states[graphid_2].Position)
+		return distance, distance < maxFSODistance
+	}
+	gs := e.gsdata[graphid_2-len(e.satellites)]
+	if position, ok := groundPosition(gs); ok {
+		distance = position.Distance(sat.Position)
+		return distance, distance < maxFSODistance
+	}
+	// without ground station positions the slant range from the look angles is used
+	visible, distance := space.SatelliteVisible(&gs, sat.Position, sat.LatLong)
+	return distance, visible
+}
+
+// PathReachable reports whether every link on the current paths is in range at step index.
+// Steps past the end of the orbital data are treated as reachable.
+func (e *Engine) PathReachable(index int) bool {
+	if len(e.satellites) == 0 || index >= e.orbits.Steps() {
+		return true
+	}
+	for _, f := range e.flows {
+		for i := 0; i < len(f.path)-1; i++ {
+			if _, reachable := e.linkDistance(index, f.path[i], f.path[i+1]); !reachable {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (e *Engine) updateRoutes(index int) {
+	log.Info().Int("index", index).Msg("L3 update")
+
+	// create edges between satellites with a link in the topology (edge cost calculated from distance)
+	states := e.orbits.At(index)
+	e.topology.SetupEdges(e.graph, index, states, e.scenario.Links.MaxFSODistance)
+	if len(e.gsdata) > 0 && index < len(e.gsdata[0].Position) {
+		graph.SetupGraphGroundStationEdgesV2(e.graph, index, states, e.gsdata, e.scenario.Links.MaxFSODistance)
+	} else {
+		graph.SetupGraphGroundStationEdges(e.graph, index, states, e.gsdata, e.scenario.Links.MaxFSODistance)
+	}
+	e.limits.RemoveDazzledGroundEdges(e.graph, index, states, e.gsdata)
+
+	var changed []*flow
+	var start, stop []string
+	var forwarding map[int][]string
+	var nextNetworkLinks []string
+	if e.scenario.NetworkRouting() {
+		nextNetworkLinks, forwarding = e.updateForwarding()
+		start = append(start, e.linkRefs.acquire(linkset.Sub(nextNetworkLinks, e.networkLinks))...)
+	}
+	nextlinks := make(map[*flow][]string)
+	for _, f := range e.flows {
+		if e.policy.MakeBeforeBreak() {
+			// satellites of the previous path are only kept until the next route update
+			f.prevSats = nil
+			f.prevSatsL2Path = nil
+		}
+		// by adding the GS index to the number of satellites we get the GS vertex index in the graph
+		nextPath, nextPathDistance, err := graph.GetShortestPath(e.graph, e.graphSize, f.connection.Source+len(e.satellites), f.connection.Destination+len(e.satellites))
+		if err != nil {
+			log.Error().Err(err).Str("flow", f.name).Msg("Error in shortest path")
+			continue
+		}
+		if len(nextPath) == 0 {
+			log.Warn().Int("index", index).Str("flow", f.name).Msg("no path found available")
+			continue
+		}
+		if slices.Equal(f.path, nextPath) {
+			continue
+		}
+		log.Info().Str("flow", f.name).Int64("pathDistance", f.pathDistance).Int64("nextPathDistance", nextPathDistance).Ints("path", nextPath).Int("index", index).Msg("path change")
+		e.logRouteChange(index, nextPath, nextPathDistance)
+		f.pathDistance = nextPathDistance
+		nextlinks[f] = f.setPath(nextPath, e.policy.MakeBeforeBreak())
+		// links of the new path are acquired for every flow before any are released,
+		// so a link moving from one flow to another is never torn down
+		start = append(start, e.linkRefs.acquire(linkset.Sub(nextlinks[f], f.links))...)
+		changed = append(changed, f)
+	}
+	for _, f := range changed {
+		stop = append(stop, e.linkRefs.release(linkset.Sub(f.links, nextlinks[f]))...)
+		f.links = nextlinks[f]
+	}
+	if e.scenario.NetworkRouting() {
+		stop = append(stop, e.linkRefs.release(linkset.Sub(e.networkLinks, nextNetworkLinks))...)
+		e.networkLinks = nextNetworkLinks
+	}
+	if len(changed) == 0 && len(forwarding) == 0 && len(start) == 0 && len(stop) == 0 {
+		return
+	}
+	e.pathChanges += len(changed)
+	e.markActive()
+	if e.scenario.NetworkRouting() {
+		// the forwarding tables already hold the routes of the flows
+		e.applyPaths(index, nil, start, stop, forwarding)
+		return
+	}
+	e.applyPaths(index, changed, start, stop, nil)
+}
+
+// updateForwarding computes the forwarding tables of every node towards every prefix from the current graph.
+// It returns the links the tables use and the route commands that turn the installed tables into the new ones.
+func (e *Engine) updateForwarding() (links []string, commands map[int][]string) {
+	nextHops := make(map[int][]int)
+	for _, prefix := range e.prefixes {
+		links = append(links, LinkName(prefix.Node, prefix.Peer))
+		if _, ok := nextHops[prefix.Node]; ok {
+			continue // an access point with several user equipment ground stations
+		}
+		next, err := graph.NextHops(e.graph, e.graphSize, prefix.Node)
+		if err != nil {
+			log.Error().Err(err).Str("node", e.containers[prefix.Node]).Msg("Error in next hops")
+			continue
+		}
+		nextHops[prefix.Node] = next
+		for node, hop := range next {
+			if hop != -1 {
+				links = append(links, LinkName(node, hop))
+			}
+		}
+	}
+	tables := routing.NetworkForwardingTables(e.prefixes, nextHops)
+	commands = e.forwarding.Diff(tables)
+	e.forwarding = tables
+	return linkset.Sub(links, nil), commands
+}
+
+// markActive sets Isactive on the satellites that are part of a path or still draining
+func (e *Engine) markActive() {
+	for i := range e.satellites {
+		e.satellites[i].Isactive = false
+	}
+	for _, f := range e.flows {
+		for _, graphid := range append(append([]int{}, f.path...), f.prevSats...) {
+			if !e.isGroundStation(graphid) {
+				e.satellites[graphid].Isactive = true
+			}
+		}
+	}
+}
+
+// applyPaths sets up the links of the new paths, applies netem, the routes of the changed flows and the forwarding table changes
+// and tears down the links no longer used
+func (e *Engine) applyPaths(index int, changed []*flow, linkStartList, linkStopList []string, forwarding map[int][]string) {
+	e.forEachLink(linkStartList, "Failed to set up link", e.backend.SetupLink)
+
+	b := newBatch()
+	e.updateNetem(index, b)
+
+	// slices of commands : "ip route replace destinationIP via nexthopIP"
+	var routeCommands []map[int]string
+	for graphid, commands := range forwarding {
+		b.add(e.containers[graphid], commands...)
+	}
+	for _, f := range changed {
+		commands, reversecommands := routing.RouteTables(f.path)
+		routeCommands = append(routeCommands, commands, reversecommands)
+		if len(f.prevSats) > 0 {
+			// commands that will only allow packets to be routed AWAY from the old sats
+			commandsPrevsats, reversecommandsPrevsats := routing.RouteTablesPrevSats(f.path, f.prevSats, f.prevSatsL2Path)
+			routeCommands = append(routeCommands, commandsPrevsats, reversecommandsPrevsats)
+		}
+	}
+	for _, commands := range routeCommands {
+		log.Debug().Interface("commands", commands).Msg("Routing")
+		for graphid, command := range commands {
+			b.add(e.containers[graphid], command)
+		}
+	}
+	e.runBatch(index, b)
+
+	e.forEachLink(linkStopList, "Failed to tear down link", e.backend.TearDownLink)
+}
+
+// updateNetem sets the delay of every link on the paths (and on the links kept for make-before-break)
+// and, with network routing, of every link used by the forwarding tables.
+// A link shared by several flows is only updated once. The commands are added to b.
+func (e *Engine) updateNetem(index int, b *batch) {
+	e.lastNetem = index
+	e.setDelays(index, 0, b)
+}
+
+// refreshNetem sets the delays of the same links as updateNetem offset after step index,
+// with the distances at that time from the ephemeris. The route costs and link motion are only written at the steps.
+func (e *Engine) refreshNetem(index int, offset time.Duration) {
+	b := newBatch()
+	e.setDelays(index, offset, b)
+	e.runBatch(index, b)
+}
+
+func (e *Engine) setDelays(index int, offset time.Duration, b *batch) {
+	at := e.ephemeris.Start.Add(time.Duration(index)*e.ephemeris.Step + offset)
+	updated := make(map[string]bool)
+	// apply returns the cost of the link in ms and whether the link is in range
+	apply := func(graphid_1, graphid_2 int) (int, bool) {
+		distance, reachable, err := e.DistanceAt(at, graphid_1, graphid_2)
+		if err != nil {
+			// past the last step of the orbital data, the last step is as close as it gets
+			distance, reachable = e.linkDistance(index, graphid_1, graphid_2)
+		}
+		if !reachable {
+			return 0, false
+		}
+		cost := int(math.Ceil(space.Latency(distance) * 1000))
+		link := LinkName(graphid_1, graphid_2)
+		if updated[link] {
+			return cost, true
+		}
+		updated[link] = true
+		if offset == 0 {
+			e.writeLinkMotion(index, graphid_1, graphid_2)
+		}
+		from, to := e.containers[graphid_1], e.containers[graphid_2]
+		// the interface towards a neighbour is named after the neighbour's container
+		b.add(from, QdiscCommand(e.scenario.Netem, to, cost))
+		b.add(to, QdiscCommand(e.scenario.Netem, from, cost))
+		return cost, true
+	}
+	for _, f := range e.flows {
+		// cost of every link in path order, the format route-change-plotting.py reads
+		var costs strings.Builder
+		for _, path := range [][]int{f.path, f.prevSatsL2Path} {
+			for i := 0; i < len(path)-1; i++ {
+				if e.isGroundStation(path[i]) && e.isGroundStation(path[i+1]) {
+					continue
+				}
+				if cost, ok := apply(path[i], path[i+1]); ok {
+					fmt.Fprintf(&costs, " %d", cost)
+				}
+			}
+		}
+		if offset == 0 {
+			fmt.Fprintf(e.routeCosts, "Time: %d - Flow: %s - Cost:%s\n", index, f.name, costs.String())
+		}
+	}
+	for _, link := range e.networkLinks {
+		graphid_1, graphid_2 := linkNodes(link)
+		if e.isGroundStation(graphid_1) && e.isGroundStation(graphid_2) {
+			continue
+		}
+		apply(graphid_1, graphid_2)
+	}
+}
+
+// writeLinkMotion records the relative motion of the satellites of an inter-satellite link:
+// step, link, relative speed and range rate in km/s, Doppler shift in GHz
+func (e *Engine) writeLinkMotion(index, graphid_1, graphid_2 int) {
+	if e.isGroundStation(graphid_1) || e.isGroundStation(graphid_2) {
+		return
+	}
+	states := e.orbits.At(index)
+	m := graph.SatelliteLinkMotion(states[graphid_1], states[graphid_2])
+	fmt.Fprintf(e.linkMotion, "%d\t%s\t%.3f\t%.3f\t%.3f\n", index, LinkName(graphid_1, graphid_2), m.RelativeSpeed, m.RangeRate, m.Doppler/1e9)
+}
+
+// writeIllumination records the satellites whose illumination changed at step index, every satellite at the first step:
+// step, satellite id, sunlit, penumbra or umbra
+func (e *Engine) writeIllumination(index int) {
+	illuminated := space.Illuminations(e.orbits.At(index), e.limits.Sun(index))
+	for i, state := range illuminated {
+		if e.illuminated == nil || e.illuminated[i] != state {
+			fmt.Fprintf(e.illumination, "%d\t%d\t%s\n", index, e.satellites[i].SatelliteId, state)
+		}
+	}
+	e.illuminated = illuminated
+}
+
+func (e *Engine) runCommand(node string, command string) {
+	if err := e.backend.RunCommand(node, command); err != nil {
+		log.Error().Err(err).Str("node", node).Str("command", command).Msg("command failed")
+	}
+}
+
+// runCommands runs the scenario commands due at step index
+func (e *Engine) runCommands(index int) {
+	for _, c := range e.scenario.Commands {
+		if int(c.At/e.scenario.Time.Step) != index {
+			continue
+		}
+		command := e.expandCommand(c.Command)
+		log.Info().Str("node", c.Node).Str("command", command).Msg("running scenario command")
+		e.commands.Add(1)
+		go func(node string) {
+			defer e.commands.Done()
+			e.runCommand(node, command)
+		}(c.Node)
+	}
+}
+
+// expandCommand replaces "{ip:NODE}" with the address NODE has on the first active link it is part of
+func (e *Engine) expandCommand(command string) string {
+	for {
+		start := strings.Index(command, "{ip:")
+		if start == -1 {
+			return command
+		}
+		end := strings.Index(command[start:], "}")
+		if end == -1 {
+			return command
+		}
+		node := command[start+len("{ip:") : start+end]
+		command = command[:start] + e.nodeIP(node) + command[start+end+1:]
+	}
+}
+
+func (e *Engine) nodeIP(container string) string {
+	for _, f := range e.flows {
+		for _, link := range f.links {
+			details := e.links[link]
+			if details.NodeOneId == container {
+				return details.NodeOneIP
+			}
+			if details.NodeTwoId == container {
+				return details.NodeTwoIP
+			}
+		}
+	}
+	log.Warn().Str("node", container).Msg("node has no active link to take an address from")
+	return ""
+}
+
+// logRouteChange appends the path change in the format ReadRouteChangeTimes reads
+func (e *Engine) logRouteChange(index int, path []int, distance int64) {
+	var pathUnits string
+	for _, graphid := range path {
+		pathUnits += e.containers[graphid] + " "
+	}
+	_, err := fmt.Fprintf(e.routeChanges, "Path change found at time %d\t - path distance: %d\t - path units: %s\n", index, distance, pathUnits)
+	if err != nil {
+		log.Error().Err(err).Msg("Error writing new path to file")
+	}
+}
+
+// QdiscCommand installs or replaces the netem qdisc on the interface towards a neighbour, delay in milliseconds.
+// limit is the number of packets netem may queue, rate is the emulated link capacity.
+func QdiscCommand(netem scenario.Netem, net_if string, delay int) string {
+	return fmt.Sprintf("tc qdisc replace dev %s root netem delay %dms rate %s limit %d", net_if, delay, netem.Rate, netem.Limit)
+}
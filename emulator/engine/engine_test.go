@@ -0,0 +1,376 @@
+package engine
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"project/backend"
+	"project/scenario"
+	"project/space"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// testNetwork lays the nodes out along the x axis (km): Madrid 0, Sat1 1000, Sat2 3000, Sat3 5000, Tokyo 6000.
+// Sat4 is a detour at (3000, 500) and Sat2 leaves the constellation after step 0.
+// Ground stations are on the surface at z 6378 and satellites 1000 km above them, so every satellite
+// is in sight of the others and above the horizon of the ground station below it.
+func testNetwork(steps int) ([]space.OrbitalData, []space.GroundStation) {
+	const surface, orbit = 6378, 7378
+	fixed := func(v space.Vector3) []space.Vector3 {
+		positions := make([]space.Vector3, steps)
+		for i := range positions {
+			positions[i] = v
+		}
+		return positions
+	}
+	satellite := func(id int, positions []space.Vector3) space.OrbitalData {
+		return space.OrbitalData{SatelliteId: id, Position: positions, Velocity: make([]space.Vector3, steps), LatLong: make([]space.LatLong, steps)}
+	}
+	sat2 := fixed(space.Vector3{X: 3000, Y: 5000, Z: orbit})
+	sat2[0] = space.Vector3{X: 3000, Z: orbit}
+	satdata := []space.OrbitalData{
+		satellite(1, fixed(space.Vector3{X: 1000, Z: orbit})),
+		satellite(2, sat2),
+		satellite(3, fixed(space.Vector3{X: 5000, Z: orbit})),
+		satellite(4, fixed(space.Vector3{X: 3000, Y: 500, Z: orbit})),
+	}
+	groundstation := func(title string, lat, long float64, ap bool, x float64) space.GroundStation {
+		gs := space.GroundStationGen(title, 0, lat, long, ap)
+		gs.Position = fixed(space.Vector3{X: x, Z: surface})
+		return gs
+	}
+	gsdata := []space.GroundStation{
+		groundstation("Madrid", 40, -3, true, 0),
+		groundstation("ElAlamo", 40.001, -3, false, 0),
+		groundstation("Tokyo", 35, 139, true, 6000),
+		groundstation("Koto", 35.001, 139, false, 6000),
+	}
+	return satdata, gsdata
+}
+
+func testScenario() scenario.Scenario {
+	sc := scenario.Default()
+	sc.Time.Step = scenario.Duration(time.Second)
+	sc.Time.Duration = scenario.Duration(3 * time.Second)
+	sc.Policy.L2Interval = sc.Time.Step
+	sc.Links.MaxFSODistance = 2100
+	return sc
+}
+
+func testEngine(t *testing.T, policy Policy) (*Engine, *backend.Fake) {
+	sc := testScenario()
+	satdata, gsdata := testNetwork(sc.Steps())
+	return startEngine(t, sc, policy, satdata, gsdata)
+}
+
+func startEngine(t *testing.T, sc scenario.Scenario, policy Policy, satdata []space.OrbitalData, gsdata []space.GroundStation) (*Engine, *backend.Fake) {
+	fake := backend.NewFake()
+	e, err := New(sc, policy, fake, space.NewSliceProvider(satdata, sc.Time.Start), gsdata, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	if err := e.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return e, fake
+}
+
+func TestStepSetsUpPath(t *testing.T) {
+	e, fake := testEngine(t, &Periodic{Interval: 1})
+	e.Step(0)
+	if !slices.Equal(e.Path(), []int{5, 4, 0, 1, 2, 6, 7}) {
+		t.Fatalf("wrong path %v", e.Path())
+	}
+	if len(fake.Links()) != 6 {
+		t.Errorf("expected the 6 links of the path to be up, got %v", fake.Links())
+	}
+	// 2000 km is 6.67 ms
+	if !slices.Contains(fake.Commands("Sat1"), "tc qdisc replace dev Sat2 root netem delay 7ms rate 100mbit limit 500") {
+		t.Error(fake.Commands("Sat1"))
+	}
+	var routes int
+	for _, command := range fake.Commands("GSElAlamo") {
+		if strings.HasPrefix(command, "ip route replace") {
+			routes++
+		}
+	}
+	if routes != 1 {
+		t.Errorf("expected one route in GSElAlamo, got %v", fake.Commands("GSElAlamo"))
+	}
+}
+
+func TestStepTearsDownOldPath(t *testing.T) {
+	e, fake := testEngine(t, &Periodic{Interval: 1})
+	e.Step(0)
+	e.Step(1)
+	if !slices.Equal(e.Path(), []int{5, 4, 0, 3, 2, 6, 7}) {
+		t.Fatalf("wrong path %v", e.Path())
+	}
+	links := fake.Links()
+	if len(links) != 6 {
+		t.Errorf("expected 6 links, got %v", links)
+	}
+	if _, up := links["P7-Link-S2-S1"]; up {
+		t.Error("link to the satellite that left the path is still up")
+	}
+}
+
+func TestNoDropKeepsPreviousSatellites(t *testing.T) {
+	e, fake := testEngine(t, &NoDrop{Periodic{Interval: 1}})
+	e.Step(0)
+	e.Step(1)
+	links := fake.Links()
+	if len(links) != 8 {
+		t.Errorf("expected the links of Sat2 to be kept, got %v", links)
+	}
+	var routes int
+	for _, command := range fake.Commands("Sat2") {
+		if strings.HasPrefix(command, "ip route replace") {
+			routes++
+		}
+	}
+	// two routes from step 0 and the forward and reverse route away from Sat2
+	if routes != 4 {
+		t.Errorf("expected routes away from Sat2, got %v", fake.Commands("Sat2"))
+	}
+	e.Step(2)
+	if len(fake.Links()) != 8 {
+		t.Errorf("links of Sat2 are kept until the path changes again, got %v", fake.Links())
+	}
+}
+
+func TestSharedLinksAreReferenceCounted(t *testing.T) {
+	sc := testScenario()
+	sc.Connections = append(sc.Connections, scenario.Connection{Source: "Getafe", Destination: "Chiba"})
+	satdata, gsdata := testNetwork(sc.Steps())
+	getafe := space.GroundStationGen("Getafe", 0, 40.002, -3, false)
+	getafe.Position = gsdata[1].Position
+	chiba := space.GroundStationGen("Chiba", 0, 35.002, 139, false)
+	chiba.Position = gsdata[3].Position
+	gsdata = append(gsdata, getafe, chiba)
+	e, fake := startEngine(t, sc, &Periodic{Interval: 1}, satdata, gsdata)
+
+	e.Step(0)
+	paths := e.Paths()
+	if len(paths) != 2 || !slices.Equal(paths[1], []int{8, 4, 0, 1, 2, 6, 9}) {
+		t.Fatalf("wrong paths %v", paths)
+	}
+	// the 4 links between Madrid and Tokyo are shared, each flow has its own two access point links
+	if len(fake.Links()) != 8 {
+		t.Errorf("expected 8 links, got %v", fake.Links())
+	}
+	e.Step(1)
+	var setups, teardowns int
+	for _, call := range fake.Calls() {
+		switch call.Method {
+		case "SetupLink":
+			setups++
+		case "TearDownLink":
+			teardowns++
+		}
+	}
+	if setups != 10 || teardowns != 2 || len(fake.Links()) != 8 {
+		t.Errorf("shared links were set up or torn down more than once: %d setups, %d teardowns, %v", setups, teardowns, fake.Links())
+	}
+	if e.PathChanges() != 4 {
+		t.Errorf("expected 4 path changes, got %d", e.PathChanges())
+	}
+}
+
+func TestLinkRefs(t *testing.T) {
+	refs := make(linkRefs)
+	if start := refs.acquire([]string{"S0-S1", "S1-S2"}); len(start) != 2 {
+		t.Error(start)
+	}
+	if start := refs.acquire([]string{"S1-S2", "S2-S3"}); !slices.Equal(start, []string{"S2-S3"}) {
+		t.Error(start)
+	}
+	if stop := refs.release([]string{"S0-S1", "S1-S2"}); !slices.Equal(stop, []string{"S0-S1"}) {
+		t.Error(stop)
+	}
+	if stop := refs.release([]string{"S1-S2", "S2-S3"}); len(stop) != 2 || len(refs) != 0 {
+		t.Error(stop, refs)
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	e, _ := testEngine(t, &Periodic{Interval: 1})
+	e.Step(0)
+	command := e.expandCommand("iperf3 -c {ip:GSKoto} -p 9191")
+	if command != "iperf3 -c "+e.links[LinkName(6, 7)].NodeOneIP+" -p 9191" {
+		t.Error(command)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	sc := testScenario()
+	satdata, gsdata := testNetwork(sc.Steps())
+	var timeline strings.Builder
+	if err := DryRun(sc, &Periodic{Interval: 1}, space.NewSliceProvider(satdata, sc.Time.Start), gsdata, t.TempDir(), &timeline); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(timeline.String()), "\n")
+	var linkUps, linkDowns int
+	for _, line := range lines {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			t.Fatalf("malformed timeline line %q", line)
+		}
+		switch fields[2] {
+		case "link-up":
+			linkUps++
+		case "link-down":
+			if fields[1] != "1" || !strings.HasPrefix(fields[0], "2022-09-11T12:00:01") {
+				t.Errorf("link torn down at the wrong time: %q", line)
+			}
+			linkDowns++
+		}
+	}
+	// 6 links at step 0, Sat1-Sat4 and Sat4-Sat3 replace the links of Sat2 at step 1
+	if linkUps != 8 || linkDowns != 2 {
+		t.Errorf("expected 8 link ups and 2 link downs, got %d and %d", linkUps, linkDowns)
+	}
+}
+
+func TestNetworkRoutingPushesTableChanges(t *testing.T) {
+	sc := testScenario()
+	sc.Routing = scenario.RoutingNetwork
+	satdata, gsdata := testNetwork(sc.Steps())
+	e, fake := startEngine(t, sc, &Periodic{Interval: 1}, satdata, gsdata)
+	routes := func(node, prefix string) (count int) {
+		for _, command := range fake.Commands(node) {
+			if strings.HasPrefix(command, prefix) {
+				count++
+			}
+		}
+		return count
+	}
+	e.Step(0)
+	// the trees towards Madrid and Tokyo use every satellite, Sat4 only on the way to Tokyo
+	if len(fake.Links()) != 8 {
+		t.Errorf("expected the 8 links of the forwarding tables, got %v", fake.Links())
+	}
+	if routes("Sat4", "ip route replace") != 2 || routes("GSElAlamo", "ip route replace") != 1 {
+		t.Errorf("expected a route to every prefix, got %v and %v", fake.Commands("Sat4"), fake.Commands("GSElAlamo"))
+	}
+	if !slices.Contains(fake.Commands("Sat4"), "tc qdisc replace dev Sat3 root netem delay 7ms rate 100mbit limit 500") {
+		t.Errorf("links only used by the tables need netem too, got %v", fake.Commands("Sat4"))
+	}
+
+	e.Step(1)
+	if len(fake.Links()) != 6 {
+		t.Errorf("expected the links of Sat2 to be torn down, got %v", fake.Links())
+	}
+	if routes("Sat2", "ip route del") != 2 {
+		t.Errorf("expected the routes of Sat2 to be deleted, got %v", fake.Commands("Sat2"))
+	}
+	// only Sat1 and Sat3 forward differently, Sat4 keeps its table
+	if routes("Sat1", "ip route replace") != 3 || routes("Sat3", "ip route replace") != 3 || routes("Sat4", "ip route replace") != 2 {
+		t.Errorf("expected only the changed routes to be pushed, got %v, %v and %v", fake.Commands("Sat1"), fake.Commands("Sat3"), fake.Commands("Sat4"))
+	}
+	e.Step(2)
+	if routes("Sat1", "ip route replace") != 3 {
+		t.Errorf("unchanged tables must not be pushed again, got %v", fake.Commands("Sat1"))
+	}
+}
+
+func TestLinkNodes(t *testing.T) {
+	node1, node2 := linkNodes(LinkName(12, 3))
+	if node1 != 3 || node2 != 12 {
+		t.Errorf("expected 3 and 12, got %d and %d", node1, node2)
+	}
+}
+
+func TestDistanceAt(t *testing.T) {
+	e, _ := testEngine(t, &Periodic{Interval: 1})
+	start := e.scenario.Time.Start
+	// on a step the distance is the one of the step
+	distance, reachable, err := e.DistanceAt(start.Add(time.Second), 1, 3)
+	if err != nil || distance != 4500 || reachable {
+		t.Errorf("Sat2-Sat4 at step 1: %g %v %v", distance, reachable, err)
+	}
+	// Sat2 moves from (3000, 0) to (3000, 5000) in the first step, half way it is 2000 km from Sat4
+	distance, reachable, err = e.DistanceAt(start.Add(500*time.Millisecond), 1, 3)
+	if err != nil || distance != 2000 || !reachable {
+		t.Errorf("Sat2-Sat4 half way through step 0: %g %v %v", distance, reachable, err)
+	}
+	// Madrid (graph id 4) is 1000 km below and 1000 km beside Sat1
+	distance, reachable, err = e.DistanceAt(start.Add(1500*time.Millisecond), 4, 0)
+	if err != nil || math.Abs(distance-1000*math.Sqrt2) > 1e-9 || !reachable {
+		t.Errorf("Madrid-Sat1: %g %v %v", distance, reachable, err)
+	}
+	if _, _, err := e.DistanceAt(start.Add(time.Minute), 0, 1); err == nil {
+		t.Error("expected an error past the end of the run")
+	}
+}
+
+func TestRefreshNetem(t *testing.T) {
+	sc := testScenario()
+	sc.Policy.NetemInterval = sc.Time.Step / 4
+	sc.Links.MaxFSODistance = 2600
+	satdata, gsdata := testNetwork(sc.Steps())
+	e, fake := startEngine(t, sc, &Periodic{Interval: 1}, satdata, gsdata)
+	e.Step(0)
+	if !slices.Equal(e.Path(), []int{5, 4, 0, 1, 2, 6, 7}) {
+		t.Fatalf("wrong path %v", e.Path())
+	}
+	// a quarter into step 0 Sat2 is at (3000, 1250), 2358 km from Sat1
+	e.refreshNetem(0, time.Duration(sc.Policy.NetemInterval))
+	if !slices.Contains(fake.Commands("Sat1"), "tc qdisc replace dev Sat2 root netem delay 8ms rate 100mbit limit 500") {
+		t.Errorf("expected the delay to Sat2 a quarter into the step, got %v", fake.Commands("Sat1"))
+	}
+}
+
+// The TLE stream serves step 0 one step after the start of the scenario, distances must be interpolated from there
+func TestDistanceAtOffsetStream(t *testing.T) {
+	sc := testScenario()
+	_, gsdata := testNetwork(sc.Steps())
+	start, step := sc.Time.Start, time.Duration(sc.Time.Step)
+	var propagators []space.Propagator
+	for i := 0; i < 2; i++ {
+		propagators = append(propagators, space.Kepler{Elements: space.Elements{Epoch: start, SemiMajorAxis: 7000 + 100*float64(i), Inclination: 0.9, RAAN: float64(i)}})
+	}
+	satellites := []space.OrbitalData{{SatelliteId: 1}, {SatelliteId: 2}}
+	orbits := space.NewStreamProvider(satellites, propagators, start.Add(step), step, sc.Steps(), 0)
+	e, err := New(sc, &Periodic{Interval: 1}, backend.NewFake(), orbits, gsdata, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	for _, offset := range []time.Duration{step, step + step/2, 2 * step} {
+		at := start.Add(offset)
+		p0, _ := propagators[0].Propagate(at)
+		p1, _ := propagators[1].Propagate(at)
+		distance, _, err := e.DistanceAt(at, 0, 1)
+		if err != nil || math.Abs(distance-p0.Distance(p1)) > 1e-3 {
+			t.Errorf("at %s: %g %v, expected %g", offset, distance, err, p0.Distance(p1))
+		}
+	}
+	if _, _, err := e.DistanceAt(start.Add(step/2), 0, 1); err == nil {
+		t.Error("expected an error before the first step of the stream")
+	}
+	// the sun exclusion checks the sun at the time of the step as well
+	if !e.limits.Start.Equal(start.Add(step)) {
+		t.Errorf("link limits start at %s, expected %s", e.limits.Start, start.Add(step))
+	}
+}
+
+func TestIlluminationRecordsChanges(t *testing.T) {
+	e, _ := testEngine(t, &Periodic{Interval: 1})
+	e.Step(0)
+	e.Step(1)
+	contents, err := os.ReadFile(filepath.Join(e.runDir, "illumination"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// high above the poles every satellite is in the sun, only the first step is recorded
+	expected := "0\t1\tsunlit\n0\t2\tsunlit\n0\t3\tsunlit\n0\t4\tsunlit\n"
+	if string(contents) != expected {
+		t.Errorf("expected\n%s, got\n%s", expected, contents)
+	}
+}
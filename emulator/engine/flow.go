@@ -0,0 +1,60 @@
+package engine
+
+import "project/linkset"
+
+// flow is the state kept for one ground station pair: its path, the links it holds and,
+// for make-before-break, the satellites of its previous path which are still draining
+type flow struct {
+	connection     Connection
+	name           string
+	path           []int
+	pathDistance   int64
+	prevSats       []int // satellites of the previous path which are kept forwarding (make-before-break)
+	prevSatsL2Path []int
+	links          []string // links held by this flow, without duplicates
+}
+
+// setPath replaces the path of the flow and returns the links the flow needs for it
+func (f *flow) setPath(path []int, makeBeforeBreak bool) (nextlinks []string) {
+	prevPath := f.path
+	f.path = path
+	nextlinks = pathLinks(path)
+	if makeBeforeBreak && len(prevPath) > 0 {
+		f.prevSats = previousSatellites(prevPath, path)
+		f.prevSatsL2Path = drainPath(f.prevSats, prevPath)
+		// links between prevSats and their previous neighbours are not torn down
+		nextlinks = append(nextlinks, heldLinks(f.prevSats, prevPath)...)
+	}
+	// subtracting nothing removes the duplicates
+	return linkset.Sub(nextlinks, nil)
+}
+
+// linkRefs counts how many flows hold each link, so a link shared by several flows
+// is only set up by the first flow that needs it and only torn down when the last flow lets go
+type linkRefs map[string]int
+
+// acquire adds a reference to every link and returns the links which were not held by any flow before
+func (refs linkRefs) acquire(links []string) (start []string) {
+	for _, link := range links {
+		if refs[link] == 0 {
+			start = append(start, link)
+		}
+		refs[link]++
+	}
+	return start
+}
+
+// release removes a reference from every link and returns the links which are no longer held by any flow
+func (refs linkRefs) release(links []string) (stop []string) {
+	for _, link := range links {
+		if refs[link] == 0 {
+			continue
+		}
+		refs[link]--
+		if refs[link] == 0 {
+			delete(refs, link)
+			stop = append(stop, link)
+		}
+	}
+	return stop
+}
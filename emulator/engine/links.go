@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"fmt"
+	"project/backend"
+	"project/graph"
+	"project/scenario"
+	"project/space"
+	"strconv"
+	"time"
+)
+
+// Connection is a pair of ground stations given by their index in the ground station slice
+type Connection struct {
+	Source      int `parquet:"source"`
+	Destination int `parquet:"destination"`
+}
+
+// Connections resolves the ground station titles of the scenario connections to indexes in gsdata
+func Connections(gsdata []space.GroundStation, pairs []scenario.Connection) (connections []Connection, err error) {
+	for _, gspair := range pairs {
+		var node1, node2 int = -1, -1
+		for i, gs := range gsdata {
+			if gspair.Source == gs.Title {
+				node1 = i
+			}
+			if gspair.Destination == gs.Title {
+				node2 = i
+			}
+		}
+		if node1 == -1 {
+			return nil, fmt.Errorf("could not find groundstation %q from connection data", gspair.Source)
+		}
+		if node2 == -1 {
+			return nil, fmt.Errorf("could not find groundstation %q from connection data", gspair.Destination)
+		}
+		connections = append(connections, Connection{node1, node2})
+	}
+	return connections, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// newTopology builds the inter-satellite link topology selected in the scenario for the satellites
+func newTopology(links scenario.Links, limits graph.LinkLimits, satellites []space.OrbitalData) graph.SatelliteTopology {
+	switch links.Topology {
+	case scenario.TopologyGrid:
+		return &graph.GridTopology{Limits: limits, Satellites: satellites}
+	case scenario.TopologyGreedy:
+		return &graph.GreedyTopology{Terminals: links.Terminals, Hysteresis: links.Hysteresis, Limits: limits}
+	}
+	return graph.RangeTopology{Limits: limits}
+}
+
+// linkLimits returns the limits of the optical links of the scenario, whose step 0 is at start
+func linkLimits(sc scenario.Scenario, start time.Time) graph.LinkLimits {
+	links := sc.Links
+	return graph.LinkLimits{
+		MaxRelativeSpeed:   links.MaxRelativeSpeed,
+		MaxDoppler:         links.MaxDoppler * 1e9,
+		GrazingHeight:      links.Grazing(),
+		SunExclusion:       links.SunExclusion,
+		GroundSunExclusion: links.GroundSunExclusion,
+		Start:              start,
+		Step:               time.Duration(sc.Time.Step),
+	}
+}
+
+// LinkName is the key of the link between two graph nodes in the link map, independent of the order of the nodes
+func LinkName(node1, node2 int) string {
+	if node1 == node2 {
+		panic("a node can not link to itself")
+	}
+	firstNode := min(node1, node2)
+	secondNode := max(node1, node2)
+	return "S" + strconv.Itoa(firstNode) + "-S" + strconv.Itoa(secondNode)
+}
+
+// linkNodes returns the graph ids of the two nodes of a link name made by LinkName
+func linkNodes(name string) (node1, node2 int) {
+	fmt.Sscanf(name, "S%d-S%d", &node1, &node2)
+	return node1, node2
+}
+
+// subnetAllocator hands out consecutive /29 subnets starting at 120.130.0.0
+type subnetAllocator struct {
+	octet1, octet2, octet3, octet4 int
+}
+
+func newSubnetAllocator() *subnetAllocator {
+	return &subnetAllocator{octet1: 120, octet2: 130}
+}
+
+// next returns the subnet and the two host addresses of the next link
+func (a *subnetAllocator) next() (subnet, nodeOneIp, nodeTwoIp string) {
+	prefix := strconv.Itoa(a.octet1) + "." + strconv.Itoa(a.octet2) + "." + strconv.Itoa(a.octet3) + "."
+	subnet = prefix + strconv.Itoa(a.octet4) + "/29"
+	nodeOneIp = prefix + strconv.Itoa(a.octet4+2)
+	nodeTwoIp = prefix + strconv.Itoa(a.octet4+3)
+	// change octets to avoid identical ip's
+	a.octet4 += 8
+	if a.octet4 == 248 {
+		a.octet4 = 0
+		a.octet3 += 1
+	}
+	if a.octet3 == 255 {
+		a.octet3 = 0
+		a.octet2 += 1
+	}
+	if a.octet2 == 255 {
+		a.octet2 = 0
+		a.octet1 += 1
+	}
+	return subnet, nodeOneIp, nodeTwoIp
+}
+
+// SetupLinkMap makes a map of every possible link and the subnet it uses, so a link can be set up quickly later.
+// containers holds the container names in graph order: satellites first, then ground stations.
+func SetupLinkMap(containers []string, satdata []space.OrbitalData, gsdata []space.GroundStation) map[string]backend.LinkDetails {
+	subnets := newSubnetAllocator()
+	links := make(map[string]backend.LinkDetails)
+	// create links between all satellites
+	for node1, sat1 := range satdata {
+		for node2, sat2 := range satdata {
+			if node1 <= node2 { // Ignore half triangle and diagonal
+				continue
+			}
+			subnet, nodeOneIp, nodeTwoIp := subnets.next()
+			links[LinkName(node1, node2)] = backend.LinkDetails{
+				NetworkName: "P7-Link-S" + strconv.Itoa(sat1.SatelliteId) + "-S" + strconv.Itoa(sat2.SatelliteId),
+				Subnet:      subnet,
+				NodeOneIP:   nodeOneIp,
+				NodeOneId:   containers[node1],
+				NodeTwoIP:   nodeTwoIp,
+				NodeTwoId:   containers[node2],
+			}
+		}
+	}
+	for node1, gs := range gsdata {
+		if !gs.IsAP {
+			continue // only make satellite links with access points on the ground
+		}
+		for node2, sat := range satdata {
+			subnet, nodeOneIp, nodeTwoIp := subnets.next()
+			links[LinkName(len(satdata)+node1, node2)] = backend.LinkDetails{
+				NetworkName: "P7-Link-G" + gs.Title + "-S" + strconv.Itoa(sat.SatelliteId),
+				Subnet:      subnet,
+				NodeOneIP:   nodeOneIp,
+				NodeOneId:   containers[len(satdata)+node1],
+				NodeTwoIP:   nodeTwoIp,
+				NodeTwoId:   containers[node2],
+			}
+		}
+	}
+	// direct links between an access point and the user equipment ground stations next to it
+	for node1, gs1 := range gsdata {
+		if !gs1.IsAP {
+			continue
+		}
+		for node2, gs2 := range gsdata {
+			if gs2.IsAP || node1 == node2 {
+				continue
+			}
+			subnet, nodeOneIp, nodeTwoIp := subnets.next()
+			links[LinkName(len(satdata)+node1, len(satdata)+node2)] = backend.LinkDetails{
+				NetworkName: "P7-Link-AP" + gs1.Title + "-UE" + gs2.Title,
+				Subnet:      subnet,
+				NodeOneIP:   nodeOneIp,
+				NodeOneId:   containers[len(satdata)+node2],
+				NodeTwoIP:   nodeTwoIp,
+				NodeTwoId:   containers[len(satdata)+node1],
+			}
+		}
+	}
+	return links
+}
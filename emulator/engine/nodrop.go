@@ -0,0 +1,70 @@
+package engine
+
+import "golang.org/x/exp/slices"
+
+// pathLinks returns the names of the links between consecutive nodes of a path
+func pathLinks(path []int) (links []string) {
+	for i := 0; i < len(path)-1; i++ {
+		links = append(links, LinkName(path[i], path[i+1]))
+	}
+	return links
+}
+
+// previousSatellites returns the nodes of prevPath that are not part of path
+func previousSatellites(prevPath, path []int) (prevSats []int) {
+	for _, node := range prevPath {
+		if !slices.Contains(path, node) {
+			prevSats = append(prevSats, node)
+		}
+	}
+	return prevSats
+}
+
+// heldLinks returns the links between prevSats and their neighbours on the previous path,
+// these are kept so the satellites can still get their queued packets onto the new path
+func heldLinks(prevSats, prevPath []int) (links []string) {
+	lastJ := -1
+	for _, sat := range prevSats {
+		for j := range prevPath {
+			if sat != prevPath[j] {
+				continue
+			}
+			if j+1 < len(prevPath) {
+				links = append(links, LinkName(prevPath[j], prevPath[j+1]))
+			}
+			// if the neighbor with a lower index has already had its link to this satellite added
+			if lastJ != j-1 && j-1 >= 0 {
+				links = append(links, LinkName(prevPath[j], prevPath[j-1]))
+			}
+			lastJ = j
+		}
+	}
+	return links
+}
+
+// drainPath returns the stretches of prevPath that contain prevSats, including the neighbours before and after each stretch.
+// Consecutive stretches are joined, e.g. prevPath [0 1 2 3 4 5] with prevSats [2 3] gives [1 2 3 4].
+func drainPath(prevSats, prevPath []int) (prevSatsL2Path []int) {
+	lastJ := -1
+	for _, sat := range prevSats {
+		for j := range prevPath {
+			if sat != prevPath[j] {
+				continue
+			}
+			// only add the current satellite if it is MORE than 1 iteration since a satellite was added
+			if lastJ != j-1 {
+				// only add the "before-neighbor" if it is MORE than 2 iterations since a satellite was added
+				if lastJ != j-2 && j-1 >= 0 {
+					prevSatsL2Path = append(prevSatsL2Path, prevPath[j-1])
+				}
+				prevSatsL2Path = append(prevSatsL2Path, prevPath[j])
+			}
+			// always add the "after-neighbor"
+			if j+1 < len(prevPath) {
+				prevSatsL2Path = append(prevSatsL2Path, prevPath[j+1])
+			}
+			lastJ = j
+		}
+	}
+	return prevSatsL2Path
+}
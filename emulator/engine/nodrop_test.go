@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestDrainPath(t *testing.T) {
+	prevPath := []int{10, 11, 2, 3, 4, 12, 13}
+	path := []int{10, 11, 2, 5, 12, 13}
+	prevSats := previousSatellites(prevPath, path)
+	if !slices.Equal(prevSats, []int{3, 4}) {
+		t.Fatalf("wrong previous satellites %v", prevSats)
+	}
+	if l2path := drainPath(prevSats, prevPath); !slices.Equal(l2path, []int{2, 3, 4, 12}) {
+		t.Errorf("wrong drain path %v", l2path)
+	}
+	held := heldLinks(prevSats, prevPath)
+	if len(held) != 3 || !slices.Contains(held, LinkName(2, 3)) || !slices.Contains(held, LinkName(4, 12)) {
+		t.Errorf("wrong held links %v", held)
+	}
+}
+
+func TestLinkName(t *testing.T) {
+	if LinkName(7, 3) != LinkName(3, 7) || LinkName(3, 7) != "S3-S7" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"project/scenario"
+	"strconv"
+	"strings"
+)
+
+// View is the part of the engine a Policy may look at when deciding on a route update
+type View interface {
+	// PathReachable reports whether every link of the current path is still within range at step index
+	PathReachable(index int) bool
+}
+
+// Policy decides when the engine recomputes the path (an L3 update)
+type Policy interface {
+	Name() string
+	// UpdateRoutes is called once per step and reports whether the path should be recomputed at step index
+	UpdateRoutes(index int, view View) bool
+	// MakeBeforeBreak reports whether satellites that leave the path keep their links and routes
+	// for one more route update, so packets queued on them are not dropped
+	MakeBeforeBreak() bool
+}
+
+// NewPolicy builds the policy selected in the scenario
+func NewPolicy(sc scenario.Scenario) (Policy, error) {
+	switch sc.Policy.Name {
+	case scenario.PolicyPeriodic:
+		return &Periodic{Interval: sc.L3Steps()}, nil
+	case scenario.PolicyNoDrop:
+		return &NoDrop{Periodic{Interval: sc.L3Steps()}}, nil
+	case scenario.PolicyShortestPath:
+		times, err := ReadRouteChangeTimes(sc.Policy.ChangeFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewChangeTimes(times), nil
+	case scenario.PolicyPredictedBreak:
+		return &PredictedBreak{Lookahead: sc.Policy.Lookahead}, nil
+	}
+	return nil, fmt.Errorf("unknown policy %q", sc.Policy.Name)
+}
+
+// Periodic recomputes the path every Interval steps
+type Periodic struct {
+	Interval int
+}
+
+func (p *Periodic) Name() string { return scenario.PolicyPeriodic }
+
+func (p *Periodic) UpdateRoutes(index int, view View) bool {
+	return index%p.Interval == 0
+}
+
+func (p *Periodic) MakeBeforeBreak() bool { return false }
+
+// NoDrop updates periodically but keeps the satellites of the previous path forwarding towards the new path
+type NoDrop struct {
+	Periodic
+}
+
+func (p *NoDrop) Name() string { return scenario.PolicyNoDrop }
+
+func (p *NoDrop) MakeBeforeBreak() bool { return true }
+
+// ChangeTimes recomputes the path only at the steps where the shortest path is known to change
+type ChangeTimes struct {
+	times map[int]bool
+}
+
+func NewChangeTimes(times []int) *ChangeTimes {
+	p := &ChangeTimes{times: make(map[int]bool)}
+	for _, t := range times {
+		p.times[t] = true
+	}
+	return p
+}
+
+func (p *ChangeTimes) Name() string { return scenario.PolicyShortestPath }
+
+func (p *ChangeTimes) UpdateRoutes(index int, view View) bool {
+	return index == 0 || p.times[index]
+}
+
+func (p *ChangeTimes) MakeBeforeBreak() bool { return false }
+
+// PredictedBreak keeps the current path until a link on it is predicted to be out of range Lookahead steps ahead,
+// and then recomputes the path on the following step
+type PredictedBreak struct {
+	Lookahead int
+	pending   bool
+}
+
+func (p *PredictedBreak) Name() string { return scenario.PolicyPredictedBreak }
+
+func (p *PredictedBreak) UpdateRoutes(index int, view View) bool {
+	if index == 0 || p.pending {
+		p.pending = false
+		return true
+	}
+	if !view.PathReachable(index + p.Lookahead) {
+		p.pending = true
+	}
+	return false
+}
+
+func (p *PredictedBreak) MakeBeforeBreak() bool { return false }
+
+// ReadRouteChangeTimes returns the steps of every path change in a route change file:
+// "Path change found at time 34\t - path distance: 43485\t - path units: GSElAlamo GSMadrid Sat75 ..."
+func ReadRouteChangeTimes(path string) ([]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var times []int
+	fileScanner := bufio.NewScanner(f)
+	for lineNumber := 1; fileScanner.Scan(); lineNumber++ {
+		line := fileScanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Path change found at time"))
+		if !strings.HasPrefix(line, "Path change found at time") || len(fields) == 0 {
+			return nil, fmt.Errorf("%s:%d: not a route change line", path, lineNumber)
+		}
+		changeTime, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		times = append(times, changeTime)
+	}
+	return times, fileScanner.Err()
+}
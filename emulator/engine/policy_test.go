@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type reachableView struct {
+	unreachable map[int]bool
+}
+
+func (v reachableView) PathReachable(index int) bool {
+	return !v.unreachable[index]
+}
+
+func TestPeriodic(t *testing.T) {
+	p := &Periodic{Interval: 3}
+	var updates []int
+	for index := 0; index < 10; index++ {
+		if p.UpdateRoutes(index, reachableView{}) {
+			updates = append(updates, index)
+		}
+	}
+	if len(updates) != 4 || updates[3] != 9 {
+		t.Errorf("expected updates at 0 3 6 9, got %v", updates)
+	}
+}
+
+func TestPredictedBreak(t *testing.T) {
+	p := &PredictedBreak{Lookahead: 2}
+	view := reachableView{unreachable: map[int]bool{7: true}}
+	var updates []int
+	for index := 0; index < 10; index++ {
+		if p.UpdateRoutes(index, view) {
+			updates = append(updates, index)
+		}
+	}
+	// the break at 7 is seen at step 5 and the path is recomputed at step 6
+	if len(updates) != 2 || updates[1] != 6 {
+		t.Errorf("expected updates at 0 6, got %v", updates)
+	}
+}
+
+func TestReadRouteChangeTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shortest_paths")
+	content := "Path change found at time 0\t - path distance: 43485\t - path units: GSElAlamo GSMadrid Sat75 \n" +
+		"Path change found at time 34\t - path distance: 43480\t - path units: GSElAlamo GSMadrid Sat76 \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	times, err := ReadRouteChangeTimes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewChangeTimes(times)
+	if !p.UpdateRoutes(34, reachableView{}) || p.UpdateRoutes(33, reachableView{}) {
+		t.Errorf("wrong change times %v", times)
+	}
+
+	if err := os.WriteFile(path, []byte("Path change found at time x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRouteChangeTimes(path); err == nil {
+		t.Error("expected error for malformed line")
+	}
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"project/space"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/yourbasic/graph"
+)
+
+// LinkLimits decide which satellites in range can be linked: the line of sight must pass above GrazingHeight,
+// the relative motion must stay within the tracking limits of the laser terminals and neither terminal may look
+// closer to the sun than SunExclusion, zero meaning no limit. GroundSunExclusion is the same for the optical
+// links between ground stations and satellites. Step index is at Start+index*Step, which places the sun.
+type LinkLimits struct {
+	MaxRelativeSpeed   float64 // km/s
+	MaxDoppler         float64 // Hz
+	GrazingHeight      float64 // km, 0 only keeps links from passing through the earth
+	SunExclusion       float64 // degrees
+	GroundSunExclusion float64 // degrees
+	Start              time.Time
+	Step               time.Duration
+}
+
+// Sun returns the position of the sun at step index
+func (l LinkLimits) Sun(index int) space.Vector3 {
+	return space.SunPosition(l.Start.Add(time.Duration(index) * l.Step))
+}
+
+// LinkMotion is how the two satellites of a link move relative to each other
+type LinkMotion struct {
+	RelativeSpeed float64 // km/s
+	RangeRate     float64 // km/s, positive while the satellites move apart
+	Doppler       float64 // Hz, at space.CarrierFrequency
+}
+
+// SatelliteLinkMotion returns the relative motion of two satellites from their states
+func SatelliteLinkMotion(sat1, sat2 space.State) LinkMotion {
+	speed, rangeRate := space.RelativeMotion(sat1.Position, sat1.Velocity, sat2.Position, sat2.Velocity)
+	return LinkMotion{
+		RelativeSpeed: speed,
+		RangeRate:     rangeRate,
+		Doppler:       space.Doppler(space.CarrierFrequency, rangeRate),
+	}
+}
+
+// Feasible reports whether terminals with the limits can track a link with the motion
+func (l LinkLimits) Feasible(m LinkMotion) bool {
+	if l.MaxRelativeSpeed > 0 && m.RelativeSpeed > l.MaxRelativeSpeed {
+		return false
+	}
+	if l.MaxDoppler > 0 && m.Doppler > l.MaxDoppler {
+		return false
+	}
+	return true
+}
+
+// filter removes the pairs that are out of sight, that the terminals can not track or that look into the sun at step index from pairs
+func (l LinkLimits) filter(index int, states []space.State, pairs map[pair]float64) {
+	tracking := l.MaxRelativeSpeed > 0 || l.MaxDoppler > 0
+	var sun space.Vector3
+	if l.SunExclusion > 0 {
+		sun = l.Sun(index)
+	}
+	occluded, untrackable, dazzled := 0, 0, 0
+	for p := range pairs {
+		sat1, sat2 := states[p.node1], states[p.node2]
+		if !space.InLineOfSight(sat1.Position, sat2.Position, l.GrazingHeight) {
+			delete(pairs, p)
+			occluded++
+		} else if tracking && !l.Feasible(SatelliteLinkMotion(sat1, sat2)) {
+			delete(pairs, p)
+			untrackable++
+		} else if l.SunExclusion > 0 && space.LinkSunAngle(sat1.Position, sat2.Position, sun) < l.SunExclusion {
+			delete(pairs, p)
+			dazzled++
+		}
+	}
+	log.Debug().Int("index", index).Int("occluded", occluded).Int("untrackable", untrackable).Int("dazzled", dazzled).Msg("rejected links")
+}
+
+// RemoveDazzledGroundEdges removes the edges between access points and satellites at step index whose terminals
+// look closer to the sun than GroundSunExclusion. Ground stations without positions are placed from their latitude and longitude.
+func (l LinkLimits) RemoveDazzledGroundEdges(g *graph.Mutable, index int, states []space.State, gsdata []space.GroundStation) {
+	if l.GroundSunExclusion <= 0 {
+		return
+	}
+	sun := l.Sun(index)
+	size := len(states) + len(gsdata)
+	dazzled := 0
+	for gsid, gs := range gsdata {
+		if !gs.IsAP {
+			continue
+		}
+		position := gs.PositionAt(l.Start.Add(time.Duration(index) * l.Step))
+		if index < len(gs.Position) {
+			position = gs.Position[index]
+		}
+		for node1, sat := range states {
+			node2 := len(states) + gsid
+			if !g.Edge(node1, node2) || g.Cost(node1, node2) < 0 {
+				continue
+			}
+			if space.LinkSunAngle(position, sat.Position, sun) >= l.GroundSunExclusion {
+				continue
+			}
+			if err := RemoveBoth(g, size, node2, node1); err != nil {
+				log.Error().Err(err).Str("gsname", gs.Title).Msg("failed to remove path from graph")
+			}
+			dazzled++
+		}
+	}
+	log.Debug().Int("index", index).Int("dazzled", dazzled).Msg("rejected ground links")
+}
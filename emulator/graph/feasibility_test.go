@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"math"
+	"project/space"
+	"project/walker"
+	"testing"
+	"time"
+
+	"github.com/yourbasic/graph"
+)
+
+// counterRotating reports whether the planes of two satellites move in opposite directions
+func counterRotating(index int, sat1, sat2 space.OrbitalData) bool {
+	return sat1.OrbitNormal(index).Dot(sat2.OrbitNormal(index)) < 0
+}
+
+func TestRangeTopologyRejectsFastLinks(t *testing.T) {
+	satdata, err := walker.Presets["OneWeb"].Generate(space.ModelKepler, time.Date(2022, 9, 11, 12, 0, 0, 0, time.UTC), time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seam := func(g *graph.Mutable) (links int, maxSpeed float64) {
+		for node1 := range satdata {
+			g.Visit(node1, func(node2 int, _ int64) (skip bool) {
+				if node2 > node1 {
+					speed := SatelliteLinkMotion(satdata[node1].StateAt(0), satdata[node2].StateAt(0)).RelativeSpeed
+					maxSpeed = math.Max(maxSpeed, speed)
+					if counterRotating(0, satdata[node1], satdata[node2]) {
+						links++
+					}
+				}
+				return
+			})
+		}
+		return links, maxSpeed
+	}
+
+	unlimited := InstantiateGraph(len(satdata))
+	RangeTopology{}.SetupEdges(unlimited, 0, space.States(satdata, 0), 3000)
+	links, maxSpeed := seam(unlimited)
+	if links == 0 {
+		t.Fatal("expected links between counter-rotating planes without limits")
+	}
+	t.Logf("%d links between counter-rotating planes, fastest %f km/s", links, maxSpeed)
+
+	limited := InstantiateGraph(len(satdata))
+	RangeTopology{Limits: LinkLimits{MaxRelativeSpeed: 5}}.SetupEdges(limited, 0, space.States(satdata, 0), 3000)
+	links, maxSpeed = seam(limited)
+	if links != 0 || maxSpeed > 5 {
+		t.Errorf("%d links between counter-rotating planes and links at %f km/s with a 5 km/s limit", links, maxSpeed)
+	}
+	if limited.Degree(0) == 0 {
+		t.Error("the limit removed the links within the planes")
+	}
+}
+
+func TestLinkLimitsDoppler(t *testing.T) {
+	limits := LinkLimits{MaxDoppler: 1e9}
+	if !limits.Feasible(LinkMotion{RelativeSpeed: 14, RangeRate: 1, Doppler: space.Doppler(space.CarrierFrequency, 1)}) {
+		t.Error("a 1 km/s range rate is within 1 GHz of Doppler")
+	}
+	if limits.Feasible(LinkMotion{RelativeSpeed: 14, RangeRate: -2, Doppler: space.Doppler(space.CarrierFrequency, -2)}) {
+		t.Error("a 2 km/s range rate exceeds 1 GHz of Doppler")
+	}
+	if !(LinkLimits{}).Feasible(LinkMotion{RelativeSpeed: 15, Doppler: 1e10}) {
+		t.Error("links without limits are always feasible")
+	}
+}
+
+func TestSatelliteEdgesNeedLineOfSight(t *testing.T) {
+	// three satellites at 550 km: 0 and 1 are 30 degrees apart, 0 and 2 are 60 degrees apart and hidden by the earth
+	radius := 6378 + 550.0
+	satdata := make([]space.OrbitalData, 3)
+	for i, degrees := range []float64{0, 30, 60} {
+		angle := degrees * math.Pi / 180
+		satdata[i] = space.OrbitalData{SatelliteId: i, Position: []space.Vector3{{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)}}}
+	}
+	g := InstantiateGraph(len(satdata))
+	SetupGraphSatelliteEdges(g, 0, space.States(satdata, 0), 10000)
+	if !g.Edge(0, 1) || !g.Edge(1, 2) {
+		t.Error("satellites in sight of each other are not linked")
+	}
+	if g.Edge(0, 2) {
+		t.Error("satellites hidden by the earth are linked")
+	}
+
+	// 30 degrees apart the line of sight passes at about 314 km
+	for _, c := range []struct {
+		grazing float64
+		linked  bool
+	}{{300, true}, {350, false}} {
+		g := InstantiateGraph(len(satdata))
+		RangeTopology{Limits: LinkLimits{GrazingHeight: c.grazing}}.SetupEdges(g, 0, space.States(satdata, 0), 10000)
+		if g.Edge(0, 1) != c.linked {
+			t.Errorf("grazing height %f: linked %t, expected %t", c.grazing, g.Edge(0, 1), c.linked)
+		}
+	}
+}
+
+func TestSunExclusion(t *testing.T) {
+	limits := LinkLimits{SunExclusion: 10, GroundSunExclusion: 20, Start: time.Date(2022, 9, 11, 12, 0, 0, 0, time.UTC), Step: time.Minute}
+	sun := limits.Sun(3)
+	along := func(km float64) space.Vector3 {
+		scale := km / sun.Distance(space.Vector3{})
+		return space.Vector3{X: sun.X * scale, Y: sun.Y * scale, Z: sun.Z * scale}
+	}
+	// on the day side satellite 1 is straight towards the sun from satellite 0, satellite 2 is off to the side
+	side := along(1).Cross(space.Vector3{Z: 1})
+	position0 := along(7000)
+	states := []space.State{
+		{Position: position0},
+		{Position: along(8000)},
+		{Position: space.Vector3{X: position0.X + side.X*1000, Y: position0.Y + side.Y*1000, Z: position0.Z + side.Z*1000}},
+	}
+	g := InstantiateGraph(len(states))
+	RangeTopology{Limits: limits}.SetupEdges(g, 3, states, 3000)
+	if g.Edge(0, 1) {
+		t.Error("expected the link looking into the sun to be down")
+	}
+	if !g.Edge(0, 2) {
+		t.Error("expected the link across the sun direction to be up")
+	}
+
+	// an access point below satellite 0 looks up into the sun through it
+	gsdata := []space.GroundStation{{Title: "AP", IsAP: true, Position: make([]space.Vector3, 4)}}
+	gsdata[0].Position[3] = along(6378)
+	g = InstantiateGraph(len(states) + 1)
+	AddBothCost(g, len(states)+1, 3, 0, 1)
+	AddBothCost(g, len(states)+1, 3, 2, 1)
+	limits.RemoveDazzledGroundEdges(g, 3, states, gsdata)
+	if g.Edge(3, 0) {
+		t.Error("expected the ground link looking into the sun to be down")
+	}
+	if !g.Edge(3, 2) {
+		t.Error("expected the ground link further from the sun to be up")
+	}
+}
@@ -34,6 +34,18 @@ func AddBothCost(g *graph.Mutable, v int, node1 int, node2 int, linkCost int64)
 	return nil
 }
 
+// RemoveBoth removes the edges between node1 and node2, if there are any
+func RemoveBoth(g *graph.Mutable, v int, node1 int, node2 int) error {
+	if g == nil {
+		return errors.New("there is no graph instantiated")
+	}
+	if node1 > v-1 || node2 > v-1 {
+		return errors.New("out of range")
+	}
+	g.DeleteBoth(node1, node2)
+	return nil
+}
+
 func GetShortestPath(g *graph.Mutable, v int, node1 int, node2 int) (path []int, dist int64, e error) {
 	if g == nil {
 		return nil, 0, errors.New("there is no graph instantiated")
@@ -45,62 +57,59 @@ func GetShortestPath(g *graph.Mutable, v int, node1 int, node2 int) (path []int,
 	return path, dist, nil
 }
 
-func SetupGraphSatelliteEdges(g *graph.Mutable, index int, satdata []space.OrbitalData, maxFSODistance float64) {
-	for node1, satFrom := range satdata {
-		// fmt.Println("%i", satFrom.Time_steps[index].Day())
-		for node2, satTo := range satdata {
-			if node1 == node2 {
-				continue
-			}
-			var err error
-			// relative_velocity := satFrom.Position[index].Sub(satTo.Position[index])
-			// speed := relative_velocity.Speed() > 10e3
-
-			if space.Reachable(satFrom.Position[index], satTo.Position[index], maxFSODistance) {
-
-				distance := satFrom.Position[index].Distance(satTo.Position[index]) // Refactoring space would allow on less distance computation per link
-				cost := space.Latency(distance) * 1000000
-				// inserts edges with cost between node1 and node2
-				err = AddBothCost(g, len(satdata), node1, node2, int64(cost))
-
-			} else {
-				err = AddBothCost(g, len(satdata), node1, node2, -1)
-			}
-			if err != nil {
-				log.Error().Int("satFrom", satFrom.SatelliteId).Int("satTo", satTo.SatelliteId).Err(err).Msg("Error in adding edge")
-			}
-		}
+// NextHops returns for every node the neighbour it forwards to on its shortest path towards destination,
+// -1 for the destination itself and for nodes that can not reach it. Edges are symmetric (AddBothCost),
+// so the tree of shortest paths from the destination gives the next hops towards it.
+func NextHops(g *graph.Mutable, v int, destination int) (next []int, e error) {
+	if g == nil {
+		return nil, errors.New("there is no graph instantiated")
+	}
+	if destination > v-1 {
+		return nil, errors.New("out of range")
 	}
+	next, _ = graph.ShortestPaths(g, destination)
+	return next, nil
 }
 
-func SetupGraphGroundStationEdges(g *graph.Mutable, index int, satdata []space.OrbitalData, gsdata []space.GroundStation, maxFSODistance float64) {
+// SetupGraphSatelliteEdges updates the edges between satellites to their states at step index:
+// every pair closer than maxFSODistance whose line of sight passes above space.DefaultGrazingHeight is connected,
+// edges of other pairs are removed. Satellites in range are found with a Grid instead of comparing every pair.
+func SetupGraphSatelliteEdges(g *graph.Mutable, index int, states []space.State, maxFSODistance float64) {
+	pairs := inRangePairs(states, maxFSODistance)
+	LinkLimits{GrazingHeight: space.DefaultGrazingHeight}.filter(index, states, pairs)
+	setSatelliteEdges(g, len(states), pairs)
+}
+
+// SetupGraphGroundStationEdges links access points to the satellites above their minimum elevation,
+// a satellite above the horizon is never hidden by the earth
+func SetupGraphGroundStationEdges(g *graph.Mutable, index int, states []space.State, gsdata []space.GroundStation, maxFSODistance float64) {
 	for gsid, gs := range gsdata {
 		if !gs.IsAP {
 			continue
 		}
-		for node1, sat := range satdata {
+		for node1, sat := range states {
 			/* this only works if gs ECI positions are calculated:
-			ddistance := gs.Position[index].Distance(sat.Position[index])
+			ddistance := gs.Position[index].Distance(sat.Position)
 			if ddistance <= 1500 {
-				log.Debug().Int("gsid", gsid).Int("satid", sat.SatelliteId).Float64("distance", ddistance).Msg("????? => Interesting ====>")
+				log.Debug().Int("gsid", gsid).Int("satid", node1).Float64("distance", ddistance).Msg("????? => Interesting ====>")
 			}*/
-			visible, distance := space.SatelliteVisible(&gs, sat.LatLong[index])
+			visible, distance := space.SatelliteVisible(&gs, sat.Position, sat.LatLong)
 			if visible && printOn {
 				log.Debug().Bool("visible", visible).Float64("distance", distance).Msg("satellite visibility")
 			}
-			if !visible || distance > 1500 {
-				err := AddBothCost(g, len(gsdata)+len(satdata), len(satdata)+gsid, node1, -1)
+			if !visible {
+				err := RemoveBoth(g, len(gsdata)+len(states), len(states)+gsid, node1)
 				if err != nil {
-					log.Error().Err(err).Str("gsname", gs.Title).Msg("failed to add -1 path to graph")
+					log.Error().Err(err).Str("gsname", gs.Title).Msg("failed to remove path from graph")
 				}
 				continue
 			}
 			cost := space.Latency(float64(distance)) * 1000000
 			if printOn {
-				log.Debug().Int("gsid", gsid).Int("satid", sat.SatelliteId).Float64("distance", distance).Msg("new GS->Satellite")
+				log.Debug().Int("gsid", gsid).Int("satid", node1).Float64("distance", distance).Msg("new GS->Satellite")
 			}
-			log.Info().Str("From ", gs.Title).Str("To ", sat.Title).Int64("cost", int64(cost)).Msg("V1")
-			err := AddBothCost(g, len(gsdata)+len(satdata), len(satdata)+gsid, node1, int64(cost))
+			log.Info().Str("From ", gs.Title).Int("To ", node1).Int64("cost", int64(cost)).Msg("V1")
+			err := AddBothCost(g, len(gsdata)+len(states), len(states)+gsid, node1, int64(cost))
 			if err != nil {
 				log.Error().Err(err).Str("gsname", gs.Title).Msg("failed to add cost path to graph")
 			}
@@ -111,34 +120,34 @@ func SetupGraphGroundStationEdges(g *graph.Mutable, index int, satdata []space.O
 	}
 }
 
-// uses xyz positions instead of latlong
-func SetupGraphGroundStationEdgesV2(g *graph.Mutable, index int, satdata []space.OrbitalData, gsdata []space.GroundStation, maxFSODistance float64) {
+// uses xyz positions instead of latlong, satellites below the horizon are hidden by the earth
+func SetupGraphGroundStationEdgesV2(g *graph.Mutable, index int, states []space.State, gsdata []space.GroundStation, maxFSODistance float64) {
 	for gsid, gs := range gsdata {
 		if !gs.IsAP {
 			continue
 		}
-		for node1, sat := range satdata {
+		for node1, sat := range states {
 
 			var err error
 
-			if space.Reachable(gs.Position[index], sat.Position[index], maxFSODistance) {
+			if space.Reachable(gs.Position[index], sat.Position, maxFSODistance) && space.AboveHorizon(gs.Position[index], sat.Position) {
 
-				distance := gs.Position[index].Distance(sat.Position[index]) // Refactoring space would allow on less distance computation per link
+				distance := gs.Position[index].Distance(sat.Position) // Refactoring space would allow on less distance computation per link
 				cost := space.Latency(distance) * 1000000
 				// inserts edges with cost between node1 and node2
-				//log.Info().Str("From ", gs.Title).Str("To ", sat.Title).Int64("cost", int64(cost)).Msg("V2")
-				err = AddBothCost(g, len(gsdata)+len(satdata), len(satdata)+gsid, node1, int64(cost))
+				//log.Info().Str("From ", gs.Title).Int("To ", node1).Int64("cost", int64(cost)).Msg("V2")
+				err = AddBothCost(g, len(gsdata)+len(states), len(states)+gsid, node1, int64(cost))
 
 			} else {
-				err = AddBothCost(g, len(gsdata)+len(satdata), len(satdata)+gsid, node1, -1)
+				err = RemoveBoth(g, len(gsdata)+len(states), len(states)+gsid, node1)
 			}
 			if err != nil {
-				log.Error().Int("satFrom", gs.ID).Int("satTo", sat.SatelliteId).Err(err).Msg("Error in adding edge")
+				log.Error().Int("satFrom", gs.ID).Int("satTo", node1).Err(err).Msg("Error in adding edge")
 			}
 
-			// err := AddBothCost(g, len(gsdata)+len(satdata), len(satdata)+gsid, node1, -1)
+			// err := AddBothCost(g, len(gsdata)+len(states), len(states)+gsid, node1, -1)
 
-			// err := AddBothCost(g, len(gsdata)+len(satdata), len(satdata)+gsid, node1, int64(cost))
+			// err := AddBothCost(g, len(gsdata)+len(states), len(states)+gsid, node1, int64(cost))
 			// if err != nil {
 			// 	log.Error().Err(err).Str("gsname", gs.Title).Msg("failed to add cost path to graph")
 			// }
@@ -170,9 +179,9 @@ func SetupGraphAccessPointEdges(g *graph.Mutable, graphSize int, gsdata []space.
 			}
 
 			if !visible {
-				err := AddBothCost(g, graphSize, graphOffset+gs1id, graphOffset+gs2id, -1)
+				err := RemoveBoth(g, graphSize, graphOffset+gs1id, graphOffset+gs2id)
 				if err != nil {
-					log.Error().Err(err).Str("gs1name", gs1.Title).Str("gs2name", gs2.Title).Msg("failed to add -1 path to graph")
+					log.Error().Err(err).Str("gs1name", gs1.Title).Str("gs2name", gs2.Title).Msg("failed to remove path from graph")
 				}
 				continue
 			}
@@ -192,8 +201,7 @@ func IsPathInGraph(g *graph.Mutable, path []int) (found bool, e error) {
 		return false, errors.New("there is no graph instantiated")
 	}
 	for i := 0; i < len(path)-1; i++ {
-		val := g.Cost(path[i], path[i+1])
-		if val == -1 {
+		if !g.Edge(path[i], path[i+1]) || g.Cost(path[i], path[i+1]) < 0 {
 			return false, nil
 		}
 	}
@@ -60,3 +60,24 @@ func TestLastinArray(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNextHops(t *testing.T) {
+	// 0 - 1 - 2 - 3 with a more expensive shortcut 0 - 3 and 4 unconnected
+	g := InstantiateGraph(5)
+	AddBothCost(g, 5, 0, 1, 1)
+	AddBothCost(g, 5, 1, 2, 1)
+	AddBothCost(g, 5, 2, 3, 1)
+	AddBothCost(g, 5, 0, 3, 5)
+	AddBothCost(g, 5, 3, 4, -1)
+	next, err := NextHops(g, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{1, 2, 3, -1, -1}
+	for i := range expected {
+		if next[i] != expected[i] {
+			t.Errorf("expected next hops %v, got %v", expected, next)
+			break
+		}
+	}
+}
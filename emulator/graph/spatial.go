@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"math"
+	"project/space"
+)
+
+type cell struct {
+	x, y, z int64
+}
+
+// Grid is a spatial index over positions: space is cut into cubes with sides of size km,
+// so every position closer than size to a position lies in the same or one of the 26 surrounding cubes.
+type Grid struct {
+	size      float64
+	positions []space.Vector3
+	cells     map[cell][]int
+}
+
+// NewGrid indexes positions in cubes with sides of size km, size should be the largest distance queried
+func NewGrid(positions []space.Vector3, size float64) *Grid {
+	g := &Grid{size: size, positions: positions, cells: make(map[cell][]int)}
+	for i, p := range positions {
+		c := g.cellOf(p)
+		g.cells[c] = append(g.cells[c], i)
+	}
+	return g
+}
+
+func (g *Grid) cellOf(p space.Vector3) cell {
+	return cell{int64(math.Floor(p.X / g.size)), int64(math.Floor(p.Y / g.size)), int64(math.Floor(p.Z / g.size))}
+}
+
+// Neighbours calls fn for every other position closer than maxDistance to position i, maxDistance must not exceed the grid size
+func (g *Grid) Neighbours(i int, maxDistance float64, fn func(j int, distance float64)) {
+	p := g.positions[i]
+	c := g.cellOf(p)
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for dz := int64(-1); dz <= 1; dz++ {
+				for _, j := range g.cells[cell{c.x + dx, c.y + dy, c.z + dz}] {
+					if j == i {
+						continue
+					}
+					if distance := p.Distance(g.positions[j]); distance < maxDistance {
+						fn(j, distance)
+					}
+				}
+			}
+		}
+	}
+}
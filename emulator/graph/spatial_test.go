@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"project/space"
+	"testing"
+	"time"
+
+	"github.com/yourbasic/graph"
+)
+
+// testConstellation places planes*slots satellites on circular orbits at altitude km and inclination degrees,
+// with the planes spread over 360 degrees of RAAN, every step moves them 1/1000 of an orbit on in one second
+func testConstellation(planes, slots int, altitude, inclination float64, steps int) []space.OrbitalData {
+	return testConstellationSpread(planes, slots, altitude, inclination, 360, steps)
+}
+
+// testConstellationSpread spreads the planes over spread degrees of RAAN, 180 for a Walker star
+func testConstellationSpread(planes, slots int, altitude, inclination, spread float64, steps int) []space.OrbitalData {
+	r := 6371 + altitude
+	inc := inclination * math.Pi / 180
+	var satdata []space.OrbitalData
+	for p := 0; p < planes; p++ {
+		raan := spread * math.Pi / 180 * float64(p) / float64(planes)
+		for s := 0; s < slots; s++ {
+			sat := space.OrbitalData{SatelliteId: p*slots + s, Position: make([]space.Vector3, steps)}
+			for i := 0; i < steps; i++ {
+				u := 2*math.Pi*float64(s)/float64(slots) + math.Pi*float64(p)/float64(planes*slots) + 2*math.Pi*float64(i)/1000
+				sat.Position[i] = space.Vector3{
+					X: r * (math.Cos(u)*math.Cos(raan) - math.Sin(u)*math.Cos(inc)*math.Sin(raan)),
+					Y: r * (math.Cos(u)*math.Sin(raan) + math.Sin(u)*math.Cos(inc)*math.Cos(raan)),
+					Z: r * math.Sin(u) * math.Sin(inc),
+				}
+			}
+			sat.DeriveVelocity(time.Second)
+			satdata = append(satdata, sat)
+		}
+	}
+	return satdata
+}
+
+// bruteForceSatelliteEdges is the pairwise check SetupGraphSatelliteEdges used to do, with -1 for no edge
+func bruteForceSatelliteEdges(g *graph.Mutable, index int, satdata []space.OrbitalData, maxFSODistance float64) {
+	for node1, satFrom := range satdata {
+		for node2, satTo := range satdata {
+			if node1 == node2 {
+				continue
+			}
+			if space.Reachable(satFrom.Position[index], satTo.Position[index], maxFSODistance) {
+				distance := satFrom.Position[index].Distance(satTo.Position[index])
+				AddBothCost(g, len(satdata), node1, node2, int64(space.Latency(distance)*1000000))
+			} else {
+				AddBothCost(g, len(satdata), node1, node2, -1)
+			}
+		}
+	}
+}
+
+func TestSatelliteEdgesMatchPairwiseCheck(t *testing.T) {
+	satdata := testConstellation(18, 36, 1200, 87.9, 100)
+	g := InstantiateGraph(len(satdata))
+	expected := InstantiateGraph(len(satdata))
+	for _, index := range []int{0, 50, 99} {
+		SetupGraphSatelliteEdges(g, index, space.States(satdata, index), 3000)
+		bruteForceSatelliteEdges(expected, index, satdata, 3000)
+		for node1 := range satdata {
+			for node2 := range satdata {
+				found, _ := IsPathInGraph(g, []int{node1, node2})
+				wanted, _ := IsPathInGraph(expected, []int{node1, node2})
+				if node1 != node2 && (found != wanted || g.Cost(node1, node2) != expected.Cost(node1, node2) && wanted) {
+					t.Fatalf("step %d: edge %d-%d differs from the pairwise check", index, node1, node2)
+				}
+			}
+		}
+	}
+}
+
+func TestSatelliteEdgesAreRemoved(t *testing.T) {
+	satdata := []space.OrbitalData{
+		{Position: []space.Vector3{{X: 0, Z: 7000}, {X: 0, Z: 7000}}},
+		{Position: []space.Vector3{{X: 1000, Z: 7000}, {X: 5000, Z: 7000}}},
+	}
+	g := InstantiateGraph(2)
+	SetupGraphSatelliteEdges(g, 0, space.States(satdata, 0), 3000)
+	if !g.Edge(0, 1) || !g.Edge(1, 0) {
+		t.Error("expected an edge between satellites in range")
+	}
+	SetupGraphSatelliteEdges(g, 1, space.States(satdata, 1), 3000)
+	if g.Edge(0, 1) || g.Edge(1, 0) {
+		t.Error("edge between satellites out of range was not removed")
+	}
+}
+
+var benchmarkConstellations = []struct {
+	name                  string
+	planes, slots         int
+	altitude, inclination float64
+}{
+	{"Kepler", 7, 20, 600, 98.6},
+	{"OneWeb", 18, 36, 1200, 87.9},
+	{"Starlink", 72, 22, 550, 53},
+}
+
+func BenchmarkSetupGraphSatelliteEdges(b *testing.B) {
+	for _, c := range benchmarkConstellations {
+		satdata := testConstellation(c.planes, c.slots, c.altitude, c.inclination, 100)
+		b.Run(fmt.Sprintf("%s-%d", c.name, len(satdata)), func(b *testing.B) {
+			g := InstantiateGraph(len(satdata))
+			for i := 0; i < b.N; i++ {
+				SetupGraphSatelliteEdges(g, i%100, space.States(satdata, i%100), 3000)
+			}
+		})
+	}
+}
+
+func BenchmarkPairwiseSatelliteEdges(b *testing.B) {
+	for _, c := range benchmarkConstellations {
+		satdata := testConstellation(c.planes, c.slots, c.altitude, c.inclination, 100)
+		b.Run(fmt.Sprintf("%s-%d", c.name, len(satdata)), func(b *testing.B) {
+			g := InstantiateGraph(len(satdata))
+			for i := 0; i < b.N; i++ {
+				bruteForceSatelliteEdges(g, i%100, satdata, 3000)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package graph
+
+import (
+	"math"
+	"project/space"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/yourbasic/graph"
+)
+
+// SatelliteTopology decides which satellites are connected by inter-satellite links.
+// SetupEdges sets the edges between satellites from their states at step index, GetShortestPath then works on the result.
+type SatelliteTopology interface {
+	SetupEdges(g *graph.Mutable, index int, states []space.State, maxFSODistance float64)
+}
+
+// pair is two satellites by graph id, node1 < node2
+type pair struct {
+	node1, node2 int
+}
+
+func newPair(node1, node2 int) pair {
+	if node1 > node2 {
+		node1, node2 = node2, node1
+	}
+	return pair{node1, node2}
+}
+
+// inRangePairs returns every pair of satellites closer than maxFSODistance with its distance
+func inRangePairs(states []space.State, maxFSODistance float64) map[pair]float64 {
+	positions := make([]space.Vector3, len(states))
+	for i, s := range states {
+		positions[i] = s.Position
+	}
+	grid := NewGrid(positions, maxFSODistance)
+	pairs := make(map[pair]float64)
+	for node1 := range states {
+		// every pair is added once, by the satellite with the lower graph id
+		grid.Neighbours(node1, maxFSODistance, func(node2 int, distance float64) {
+			if node2 > node1 {
+				pairs[pair{node1, node2}] = distance
+			}
+		})
+	}
+	return pairs
+}
+
+// setSatelliteEdges makes the edges between satellites the given pairs: edges of new pairs are added,
+// costs of existing pairs updated and edges of satellites which are no longer paired removed
+func setSatelliteEdges(g *graph.Mutable, satellites int, pairs map[pair]float64) {
+	for p, distance := range pairs {
+		cost := space.Latency(distance) * 1000000
+		// inserts edges with cost between node1 and node2
+		if err := AddBothCost(g, satellites, p.node1, p.node2, int64(cost)); err != nil {
+			log.Error().Int("satFrom", p.node1).Int("satTo", p.node2).Err(err).Msg("Error in adding edge")
+		}
+	}
+	for node1 := 0; node1 < satellites; node1++ {
+		var removed []int
+		g.Visit(node1, func(node2 int, _ int64) (skip bool) {
+			if node2 > node1 && node2 < satellites {
+				if _, found := pairs[pair{node1, node2}]; !found {
+					removed = append(removed, node2)
+				}
+			}
+			return
+		})
+		for _, node2 := range removed {
+			if err := RemoveBoth(g, satellites, node1, node2); err != nil {
+				log.Error().Int("satFrom", node1).Int("satTo", node2).Err(err).Msg("Error in removing edge")
+			}
+		}
+	}
+}
+
+// RangeTopology connects every pair of satellites in range of each other that the terminals can track
+type RangeTopology struct {
+	Limits LinkLimits
+}
+
+func (t RangeTopology) SetupEdges(g *graph.Mutable, index int, states []space.State, maxFSODistance float64) {
+	pairs := inRangePairs(states, maxFSODistance)
+	t.Limits.filter(index, states, pairs)
+	setSatelliteEdges(g, len(states), pairs)
+}
+
+// GridTopology is the +Grid: every satellite links to the satellites before and after it in its plane
+// and to one satellite in each neighbouring plane of its shell. The links are chosen once at the first step
+// and are only up while the satellites are in range of each other and within the Limits of the terminals.
+// Satellites, indexed by graph id, give the shell, plane and slot of every satellite if they are known,
+// otherwise the planes are found from the states at the first step and taken as a single shell.
+// Neighbouring planes moving in opposite directions, as across the seam of a Walker star constellation, are not linked.
+type GridTopology struct {
+	Limits     LinkLimits
+	Satellites []space.OrbitalData
+	links      []pair
+}
+
+func (t *GridTopology) SetupEdges(g *graph.Mutable, index int, states []space.State, maxFSODistance float64) {
+	if t.links == nil {
+		planes := assignedPlanes(t.Satellites)
+		if planes == nil {
+			planes = geometricPlanes(states)
+		}
+		t.links = gridLinks(states, planes)
+		shells := 0
+		for i, plane := range planes {
+			if i == 0 || plane.shell != planes[i-1].shell {
+				shells++
+			}
+		}
+		log.Info().Int("links", len(t.links)).Int("planes", len(planes)).Int("shells", shells).Msg("+Grid topology")
+	}
+	pairs := make(map[pair]float64, len(t.links))
+	for _, p := range t.links {
+		if distance := states[p.node1].Position.Distance(states[p.node2].Position); distance < maxFSODistance {
+			pairs[p] = distance
+		}
+	}
+	t.Limits.filter(index, states, pairs)
+	setSatelliteEdges(g, len(states), pairs)
+}
+
+// gridPlane is an orbital plane of the +Grid, its satellites by graph id ordered by slot
+type gridPlane struct {
+	shell      int
+	satellites []int
+}
+
+// assignedPlanes returns the planes the satellites are assigned to, ordered by shell and plane number.
+// It returns nil if a satellite has no plane or two satellites share a slot, as when the planes are not known.
+func assignedPlanes(satellites []space.OrbitalData) []gridPlane {
+	if len(satellites) == 0 {
+		return nil
+	}
+	type key struct{ shell, plane int }
+	members := make(map[key][]int)
+	taken := make(map[[3]int]bool, len(satellites))
+	for node, sat := range satellites {
+		slot := [3]int{sat.Shell, sat.Plane, sat.Slot}
+		if sat.Shell < 0 || sat.Plane < 0 || sat.Slot < 0 || taken[slot] {
+			return nil
+		}
+		taken[slot] = true
+		members[key{sat.Shell, sat.Plane}] = append(members[key{sat.Shell, sat.Plane}], node)
+	}
+	keys := make([]key, 0, len(members))
+	for k := range members {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].shell != keys[b].shell {
+			return keys[a].shell < keys[b].shell
+		}
+		return keys[a].plane < keys[b].plane
+	})
+	planes := make([]gridPlane, len(keys))
+	for i, k := range keys {
+		nodes := members[k]
+		sort.Slice(nodes, func(a, b int) bool { return satellites[nodes[a]].Slot < satellites[nodes[b]].Slot })
+		planes[i] = gridPlane{shell: k.shell, satellites: nodes}
+	}
+	return planes
+}
+
+// geometricPlanes returns the planes of the satellites found from their states, as a single shell
+func geometricPlanes(states []space.State) []gridPlane {
+	found := space.PlanesAt(states)
+	planes := make([]gridPlane, len(found))
+	for i, plane := range found {
+		planes[i] = gridPlane{satellites: plane.Satellites}
+	}
+	return planes
+}
+
+// gridLinks returns the +Grid links between the satellites of the planes, which are ordered by shell
+// and are neighbours within their shell, with the states at one step
+func gridLinks(states []space.State, planes []gridPlane) (links []pair) {
+	seen := make(map[pair]bool)
+	add := func(node1, node2 int) {
+		p := newPair(node1, node2)
+		if node1 != node2 && !seen[p] {
+			seen[p] = true
+			links = append(links, p)
+		}
+	}
+	for _, plane := range planes {
+		for slot, node := range plane.satellites {
+			add(node, plane.satellites[(slot+1)%len(plane.satellites)])
+		}
+	}
+	normal := func(plane gridPlane) space.Vector3 {
+		s := states[plane.satellites[0]]
+		return s.Position.Cross(s.Velocity)
+	}
+	first := 0 // the first plane of the shell of plane i
+	for i := range planes {
+		if planes[i].shell != planes[first].shell {
+			first = i
+		}
+		next := first
+		if i+1 < len(planes) && planes[i+1].shell == planes[i].shell {
+			next = i + 1
+		}
+		if next == i || normal(planes[i]).Dot(normal(planes[next])) <= 0 {
+			continue
+		}
+		for _, p := range crossPlaneLinks(states, planes[i].satellites, planes[next].satellites) {
+			add(p.node1, p.node2)
+		}
+	}
+	return links
+}
+
+// crossPlaneLinks pairs the satellites of two neighbouring planes. Planes with the same number of satellites
+// are paired slot by slot with the slot offset that gives the shortest links, otherwise every satellite
+// of the first plane is paired with the closest satellite of the second.
+func crossPlaneLinks(states []space.State, plane1, plane2 []int) (links []pair) {
+	distance := func(node1, node2 int) float64 {
+		return states[node1].Position.Distance(states[node2].Position)
+	}
+	if len(plane1) == len(plane2) {
+		best, bestTotal := 0, math.Inf(1)
+		for offset := range plane2 {
+			total := 0.0
+			for slot, node := range plane1 {
+				total += distance(node, plane2[(slot+offset)%len(plane2)])
+			}
+			if total < bestTotal {
+				best, bestTotal = offset, total
+			}
+		}
+		for slot, node := range plane1 {
+			links = append(links, newPair(node, plane2[(slot+best)%len(plane2)]))
+		}
+		return links
+	}
+	for _, node := range plane1 {
+		closest := plane2[0]
+		for _, other := range plane2 {
+			if distance(node, other) < distance(node, closest) {
+				closest = other
+			}
+		}
+		links = append(links, newPair(node, closest))
+	}
+	return links
+}
+
+// GreedyTopology gives every satellite Terminals laser terminals. Every step the pairs in range are linked
+// shortest first as long as both satellites have a free terminal. Links from the previous step count as
+// Hysteresis (a fraction) shorter, so a link is only replaced by one that is clearly shorter.
+// Pairs beyond the Limits of the terminals are no candidates.
+type GreedyTopology struct {
+	Terminals  int
+	Hysteresis float64
+	Limits     LinkLimits
+	held       map[pair]bool
+}
+
+func (t *GreedyTopology) SetupEdges(g *graph.Mutable, index int, states []space.State, maxFSODistance float64) {
+	candidates := inRangePairs(states, maxFSODistance)
+	t.Limits.filter(index, states, candidates)
+	type candidate struct {
+		pair
+		effective float64
+	}
+	sorted := make([]candidate, 0, len(candidates))
+	for p, distance := range candidates {
+		if t.held[p] {
+			distance *= 1 - t.Hysteresis
+		}
+		sorted = append(sorted, candidate{p, distance})
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].effective != sorted[b].effective {
+			return sorted[a].effective < sorted[b].effective
+		}
+		if sorted[a].node1 != sorted[b].node1 {
+			return sorted[a].node1 < sorted[b].node1
+		}
+		return sorted[a].node2 < sorted[b].node2
+	})
+	used := make([]int, len(states))
+	pairs := make(map[pair]float64)
+	for _, c := range sorted {
+		if used[c.node1] >= t.Terminals || used[c.node2] >= t.Terminals {
+			continue
+		}
+		used[c.node1]++
+		used[c.node2]++
+		pairs[c.pair] = candidates[c.pair]
+	}
+	t.held = make(map[pair]bool, len(pairs))
+	for p := range pairs {
+		t.held[p] = true
+	}
+	setSatelliteEdges(g, len(states), pairs)
+}
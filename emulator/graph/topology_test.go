@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"project/space"
+	"testing"
+
+	"github.com/yourbasic/graph"
+)
+
+func degrees(g *graph.Mutable, satellites int) []int {
+	degree := make([]int, satellites)
+	for node := 0; node < satellites; node++ {
+		g.Visit(node, func(w int, c int64) (skip bool) {
+			degree[node]++
+			return
+		})
+	}
+	return degree
+}
+
+func TestGridTopology(t *testing.T) {
+	satdata := testConstellation(18, 36, 1200, 87.9, 10)
+	g := InstantiateGraph(len(satdata))
+	topology := &GridTopology{}
+	topology.SetupEdges(g, 0, space.States(satdata, 0), 3000)
+	for node, degree := range degrees(g, len(satdata)) {
+		if degree != 4 {
+			t.Fatalf("expected 4 links for satellite %d, got %d", node, degree)
+		}
+	}
+	// the +Grid still connects satellites on opposite sides of the earth
+	path, _, err := GetShortestPath(g, len(satdata), 0, 9*36+18)
+	if err != nil || len(path) == 0 {
+		t.Errorf("no path through the +Grid: %v", err)
+	}
+	topology.SetupEdges(g, 5, space.States(satdata, 5), 3000)
+	if len(topology.links) != 2*len(satdata) {
+		t.Errorf("expected the links to stay fixed, got %d", len(topology.links))
+	}
+}
+
+func TestGridTopologySkipsSeam(t *testing.T) {
+	// planes spread over 180 degrees, the first and the last plane move in opposite directions
+	satdata := testConstellationSpread(6, 12, 600, 98.6, 180, 10)
+	g := InstantiateGraph(len(satdata))
+	(&GridTopology{}).SetupEdges(g, 0, space.States(satdata, 0), 5000)
+	degree := degrees(g, len(satdata))
+	for slot := 0; slot < 12; slot++ {
+		if degree[slot] != 3 || degree[5*12+slot] != 3 {
+			t.Fatalf("expected 3 links for the satellites next to the seam, got %v", degree)
+		}
+		if degree[2*12+slot] != 4 {
+			t.Fatalf("expected 4 links away from the seam, got %v", degree)
+		}
+	}
+}
+
+func TestGridTopologyShells(t *testing.T) {
+	// a 53 degree shell of 6 planes and a polar one of 4 planes, the polar one given in reverse order
+	inclined := testConstellation(6, 10, 550, 53, 10)
+	polar := testConstellation(4, 12, 1200, 87.9, 10)
+	for i := range inclined {
+		inclined[i].Shell, inclined[i].Plane, inclined[i].Slot = 0, i/10, i%10
+	}
+	for i := range polar {
+		polar[i].Shell, polar[i].Plane, polar[i].Slot = 1, 6+i/12, i%12
+	}
+	satdata := append([]space.OrbitalData{}, inclined...)
+	for i := len(polar) - 1; i >= 0; i-- {
+		satdata = append(satdata, polar[i])
+	}
+	g := InstantiateGraph(len(satdata))
+	topology := &GridTopology{Satellites: satdata}
+	topology.SetupEdges(g, 0, space.States(satdata, 0), 1e5)
+	// some links of planes 60 degrees apart pass through the earth, count the chosen ones instead of the edges
+	degree := make([]int, len(satdata))
+	for _, p := range topology.links {
+		degree[p.node1]++
+		degree[p.node2]++
+	}
+	for node := range satdata {
+		if degree[node] != 4 {
+			t.Fatalf("expected 4 links for satellite %d, got %d", node, degree[node])
+		}
+	}
+	for _, p := range topology.links {
+		sat1, sat2 := satdata[p.node1], satdata[p.node2]
+		if sat1.Shell != sat2.Shell {
+			t.Fatalf("link %d-%d between shells %d and %d", p.node1, p.node2, sat1.Shell, sat2.Shell)
+		}
+		if sat1.Plane == sat2.Plane {
+			perPlane := 10 + 2*sat1.Shell
+			if next := (sat1.Slot + 1) % perPlane; sat2.Slot != next && sat1.Slot != (sat2.Slot+1)%perPlane {
+				t.Errorf("link %d-%d between slots %d and %d of plane %d", p.node1, p.node2, sat1.Slot, sat2.Slot, sat1.Plane)
+			}
+		}
+	}
+}
+
+func TestAssignedPlanesUnknown(t *testing.T) {
+	// without planes, or with every satellite in the same slot as zero values are, the planes are not known
+	if assignedPlanes([]space.OrbitalData{{Shell: -1, Plane: -1, Slot: -1}}) != nil || assignedPlanes(make([]space.OrbitalData, 2)) != nil {
+		t.Error("expected no planes")
+	}
+}
+
+func TestGreedyTopologyHysteresis(t *testing.T) {
+	satdata := []space.OrbitalData{
+		{Position: []space.Vector3{{X: 0, Z: 7000}, {X: 0, Z: 7000}, {X: 0, Z: 7000}}},
+		{Position: []space.Vector3{{X: 1000, Z: 7000}, {X: 1000, Z: 7000}, {X: 1000, Z: 7000}}},
+		{Position: []space.Vector3{{X: 2100, Z: 7000}, {X: 1950, Z: 7000}, {X: 1800, Z: 7000}}},
+	}
+	g := InstantiateGraph(3)
+	topology := &GreedyTopology{Terminals: 1, Hysteresis: 0.1}
+	topology.SetupEdges(g, 0, space.States(satdata, 0), 3000)
+	if !g.Edge(0, 1) || g.Edge(1, 2) {
+		t.Error("expected the shortest link 0-1")
+	}
+	// 950 km is shorter than 1000 km but not by 10%
+	topology.SetupEdges(g, 1, space.States(satdata, 1), 3000)
+	if !g.Edge(0, 1) || g.Edge(1, 2) {
+		t.Error("expected the held link 0-1 to be kept")
+	}
+	topology.SetupEdges(g, 2, space.States(satdata, 2), 3000)
+	if g.Edge(0, 1) || !g.Edge(1, 2) {
+		t.Error("expected the link to move to the clearly shorter 1-2")
+	}
+}
+
+func TestGreedyTopologyTerminals(t *testing.T) {
+	satdata := testConstellation(18, 36, 1200, 87.9, 10)
+	g := InstantiateGraph(len(satdata))
+	(&GreedyTopology{Terminals: 3}).SetupEdges(g, 0, space.States(satdata, 0), 3000)
+	for node, degree := range degrees(g, len(satdata)) {
+		if degree > 3 || degree == 0 {
+			t.Fatalf("expected 1 to 3 links for satellite %d, got %d", node, degree)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package netns
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishvananda/netlink"
+)
+
+// parseNetem reads "tc qdisc replace dev IF root netem [delay D] [rate R] [limit N] [loss P%]".
+// ok is false if fields is not a netem command at all.
+func parseNetem(fields []string) (ifname string, attrs netlink.NetemQdiscAttrs, ok bool, err error) {
+	prefix := []string{"tc", "qdisc", "replace", "dev"}
+	if len(fields) < 7 || strings.Join(fields[:4], " ") != strings.Join(prefix, " ") || fields[5] != "root" || fields[6] != "netem" {
+		return "", attrs, false, nil
+	}
+	ifname = fields[4]
+	// tc's default queue length
+	attrs.Limit = 1000
+	options := fields[7:]
+	if len(options)%2 != 0 {
+		return ifname, attrs, true, fmt.Errorf("option %s has no value", options[len(options)-1])
+	}
+	for i := 0; i < len(options); i += 2 {
+		value := options[i+1]
+		switch options[i] {
+		case "delay":
+			delay, err := time.ParseDuration(value)
+			if err != nil {
+				return ifname, attrs, true, err
+			}
+			attrs.Latency = uint32(delay.Microseconds())
+		case "rate":
+			rate, err := parseRate(value)
+			if err != nil {
+				return ifname, attrs, true, err
+			}
+			attrs.Rate64 = rate
+		case "limit":
+			limit, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return ifname, attrs, true, err
+			}
+			attrs.Limit = uint32(limit)
+		case "loss":
+			loss, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 32)
+			if err != nil {
+				return ifname, attrs, true, err
+			}
+			attrs.Loss = float32(loss)
+		default:
+			return ifname, attrs, true, fmt.Errorf("unsupported netem option %s", options[i])
+		}
+	}
+	return ifname, attrs, true, nil
+}
+
+// rate units as tc understands them, in bytes per second
+var rateUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"tbit", 1e12 / 8}, {"gbit", 1e9 / 8}, {"mbit", 1e6 / 8}, {"kbit", 1e3 / 8},
+	{"tbps", 1e12}, {"gbps", 1e9}, {"mbps", 1e6}, {"kbps", 1e3}, {"bps", 1},
+	{"bit", 1.0 / 8},
+}
+
+// parseRate converts a tc rate such as 100mbit to bytes per second, a bare number is bits per second
+func parseRate(rate string) (uint64, error) {
+	lower := strings.ToLower(rate)
+	factor := 1.0 / 8
+	for _, unit := range rateUnits {
+		if strings.HasSuffix(lower, unit.suffix) {
+			lower = strings.TrimSuffix(lower, unit.suffix)
+			factor = unit.bytes
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(lower, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+	return uint64(value * factor), nil
+}
+
+// parseRoute reads "ip route replace DESTINATION via NEXTHOP" and "ip route del DESTINATION",
+// a destination without prefix length is a host route. A route without gateway is to be deleted.
+// ok is false if fields is not one of these commands.
+func parseRoute(fields []string) (route *netlink.Route, ok bool, err error) {
+	if len(fields) < 4 || fields[0] != "ip" || fields[1] != "route" {
+		return nil, false, nil
+	}
+	switch {
+	case fields[2] == "replace" && len(fields) == 6 && fields[4] == "via":
+	case fields[2] == "del" && len(fields) == 4:
+	default:
+		return nil, false, nil
+	}
+	destination := fields[3]
+	if !strings.Contains(destination, "/") {
+		destination += "/32"
+	}
+	_, dst, err := net.ParseCIDR(destination)
+	if err != nil {
+		return nil, true, err
+	}
+	if fields[2] == "del" {
+		return &netlink.Route{Dst: dst}, true, nil
+	}
+	gw := net.ParseIP(fields[5])
+	if gw == nil {
+		return nil, true, fmt.Errorf("invalid next hop %q", fields[5])
+	}
+	return &netlink.Route{Dst: dst, Gw: gw}, true, nil
+}
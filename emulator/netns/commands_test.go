@@ -0,0 +1,51 @@
+package netns
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNetem(t *testing.T) {
+	ifname, attrs, ok, err := parseNetem(strings.Split("tc qdisc replace dev Sat75 root netem delay 7ms rate 100mbit limit 500", " "))
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	if ifname != "Sat75" || attrs.Latency != 7000 || attrs.Rate64 != 12500000 || attrs.Limit != 500 {
+		t.Errorf("wrong netem %s %+v", ifname, attrs)
+	}
+	if _, _, ok, _ := parseNetem(strings.Split("tc qdisc show", " ")); ok {
+		t.Error("tc qdisc show is not a netem command")
+	}
+	if _, _, _, err := parseNetem(strings.Split("tc qdisc replace dev Sat75 root netem slot 7ms", " ")); err == nil {
+		t.Error("expected unsupported option error")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for rate, expected := range map[string]uint64{"100mbit": 12500000, "1gbit": 125000000, "8000": 1000, "10kbps": 10000} {
+		bytes, err := parseRate(rate)
+		if err != nil || bytes != expected {
+			t.Errorf("%s: expected %d got %d %v", rate, expected, bytes, err)
+		}
+	}
+	if _, err := parseRate("fast"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	route, ok, err := parseRoute(strings.Split("ip route replace 120.130.0.2 via 120.130.0.11", " "))
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	if route.Dst.String() != "120.130.0.2/32" || route.Gw.String() != "120.130.0.11" {
+		t.Errorf("wrong route %v", route)
+	}
+	route, ok, err = parseRoute(strings.Split("ip route del 120.130.0.0/29", " "))
+	if !ok || err != nil || route.Gw != nil || route.Dst.String() != "120.130.0.0/29" {
+		t.Errorf("wrong route delete %v %v %v", route, ok, err)
+	}
+	if _, ok, _ := parseRoute(strings.Split("ip route show", " ")); ok {
+		t.Error("ip route show is not a route replace")
+	}
+}
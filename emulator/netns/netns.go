@@ -0,0 +1,394 @@
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"project/backend"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// every namespace is named with this prefix so a later run can remove what an earlier run left behind
+const namespacePrefix = "P7-"
+
+// directory where iproute2 and netns.NewNamed keep the named namespaces
+const namespaceDir = "/var/run/netns"
+
+// interface names are limited to IFNAMSIZ-1 characters by the kernel
+const maxInterfaceName = 15
+
+type node struct {
+	mu     sync.Mutex // a netlink handle is not safe for concurrent use
+	ns     netns.NsHandle
+	handle *netlink.Handle
+}
+
+// Backend builds one network namespace per node and connects them with veth pairs.
+// Addresses, routes and netem qdiscs are configured over netlink, so no container runtime is needed.
+// It has to run as root (CAP_NET_ADMIN and CAP_SYS_ADMIN).
+type Backend struct {
+	mu        sync.Mutex
+	host      *netlink.Handle
+	nodes     map[string]*node
+	vethCount int
+	processes []*exec.Cmd
+}
+
+var _ backend.Backend = &Backend{}
+var _ backend.Batcher = &Backend{}
+
+func New() *Backend {
+	return &Backend{nodes: make(map[string]*node)}
+}
+
+func (b *Backend) Init() error {
+	if os.Geteuid() != 0 {
+		return errors.New("the netns runtime has to run as root")
+	}
+	host, err := netlink.NewHandle()
+	if err != nil {
+		return err
+	}
+	b.host = host
+	return nil
+}
+
+func (b *Backend) node(name string) (*node, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, found := b.nodes[name]
+	if !found {
+		return nil, fmt.Errorf("no node %s", name)
+	}
+	return n, nil
+}
+
+// inNamespace runs f with the calling thread moved into ns
+func inNamespace(ns netns.NsHandle, f func() error) error {
+	runtime.LockOSThread()
+	origin, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	defer origin.Close()
+	if err := netns.Set(ns); err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	ferr := f()
+	if err := netns.Set(origin); err != nil {
+		// the thread is left locked, so the runtime throws it away instead of reusing it in the wrong namespace
+		return err
+	}
+	runtime.UnlockOSThread()
+	return ferr
+}
+
+func (b *Backend) CreateNode(name string, kind backend.NodeKind) (string, error) {
+	if len(name) > maxInterfaceName {
+		return "", fmt.Errorf("node name %s is longer than %d characters and can not name an interface", name, maxInterfaceName)
+	}
+	runtime.LockOSThread()
+	origin, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return "", err
+	}
+	defer origin.Close()
+	// NewNamed moves the calling thread into the new namespace
+	ns, err := netns.NewNamed(namespacePrefix + name)
+	if err != nil {
+		// it may fail after moving the thread, when the file of the namespace can not be created or mounted
+		if err := netns.Set(origin); err != nil {
+			return "", err
+		}
+		runtime.UnlockOSThread()
+		return "", fmt.Errorf("creating namespace for %s: %w", name, err)
+	}
+	// /proc/sys/net belongs to the namespace of the thread opening it
+	forwardErr := os.WriteFile("/proc/sys/net/ipv4/ip_forward", []byte("1"), 0644)
+	if err := netns.Set(origin); err != nil {
+		ns.Close()
+		return "", err
+	}
+	runtime.UnlockOSThread()
+	if forwardErr != nil {
+		discard(name, ns)
+		return "", fmt.Errorf("enabling forwarding in %s: %w", name, forwardErr)
+	}
+
+	handle, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		discard(name, ns)
+		return "", err
+	}
+	lo, err := handle.LinkByName("lo")
+	if err == nil {
+		err = handle.LinkSetUp(lo)
+	}
+	if err != nil {
+		handle.Close()
+		discard(name, ns)
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodes[name] = &node{ns: ns, handle: handle}
+	log.Info().Str("name", name).Str("kind", kind.String()).Msg("created namespace")
+	return name, nil
+}
+
+// discard closes and deletes the namespace of a node that could not be set up
+func discard(name string, ns netns.NsHandle) {
+	ns.Close()
+	if err := netns.DeleteNamed(namespacePrefix + name); err != nil {
+		log.Warn().Err(err).Str("name", name).Msg("failed to delete namespace")
+	}
+}
+
+func (b *Backend) RemoveNode(name string) error {
+	n, err := b.node(name)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	delete(b.nodes, name)
+	b.mu.Unlock()
+	n.handle.Close()
+	n.ns.Close()
+	return netns.DeleteNamed(namespacePrefix + name)
+}
+
+// SetupLink creates a veth pair in the host namespace, moves one end into each node and
+// names each end after the node on the other side
+func (b *Backend) SetupLink(link backend.LinkDetails) error {
+	one, err := b.node(link.NodeOneId)
+	if err != nil {
+		return err
+	}
+	two, err := b.node(link.NodeTwoId)
+	if err != nil {
+		return err
+	}
+	_, subnet, err := net.ParseCIDR(link.Subnet)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.vethCount++
+	nameOne, nameTwo := fmt.Sprintf("p7v%da", b.vethCount), fmt.Sprintf("p7v%db", b.vethCount)
+	b.mu.Unlock()
+
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: nameOne}, PeerName: nameTwo}
+	if err := b.host.LinkAdd(veth); err != nil {
+		return fmt.Errorf("%s: creating veth pair: %w", link.NetworkName, err)
+	}
+	// a link that fails half way is deleted again, so it neither leaks nor blocks setting it up later
+	configured := false
+	defer func() {
+		if !configured {
+			b.deleteVeth(one, nameOne, link.NodeTwoId)
+		}
+	}()
+	if err := b.moveLink(nameOne, one); err != nil {
+		return fmt.Errorf("%s: %w", link.NetworkName, err)
+	}
+	if err := b.moveLink(nameTwo, two); err != nil {
+		return fmt.Errorf("%s: %w", link.NetworkName, err)
+	}
+	if err := one.configure(nameOne, link.NodeTwoId, link.NodeOneIP, subnet.Mask); err != nil {
+		return fmt.Errorf("%s: %w", link.NetworkName, err)
+	}
+	if err := two.configure(nameTwo, link.NodeOneId, link.NodeTwoIP, subnet.Mask); err != nil {
+		return fmt.Errorf("%s: %w", link.NetworkName, err)
+	}
+	configured = true
+	return nil
+}
+
+// deleteVeth deletes a veth pair whose first end, called name, is still in the host or already in node n,
+// where it may have been renamed to ifname. Removing one end removes the other.
+func (b *Backend) deleteVeth(n *node, name, ifname string) {
+	if l, err := b.host.LinkByName(name); err == nil {
+		if err := b.host.LinkDel(l); err != nil {
+			log.Warn().Err(err).Str("veth", name).Msg("failed to delete veth pair")
+		}
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, candidate := range []string{name, ifname} {
+		if l, err := n.handle.LinkByName(candidate); err == nil {
+			if err := n.handle.LinkDel(l); err != nil {
+				log.Warn().Err(err).Str("veth", candidate).Msg("failed to delete veth pair")
+			}
+			return
+		}
+	}
+}
+
+func (b *Backend) moveLink(name string, n *node) error {
+	l, err := b.host.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return b.host.LinkSetNsFd(l, int(n.ns))
+}
+
+func (n *node) configure(name, ifname, ip string, mask net.IPMask) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	l, err := n.handle.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	if err := n.handle.LinkSetName(l, ifname); err != nil {
+		return err
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(ip), Mask: mask}}
+	if err := n.handle.AddrAdd(l, addr); err != nil {
+		return err
+	}
+	return n.handle.LinkSetUp(l)
+}
+
+// TearDownLink deletes the veth pair, removing one end removes the other
+func (b *Backend) TearDownLink(link backend.LinkDetails) error {
+	one, err := b.node(link.NodeOneId)
+	if err != nil {
+		return err
+	}
+	one.mu.Lock()
+	defer one.mu.Unlock()
+	l, err := one.handle.LinkByName(link.NodeTwoId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", link.NetworkName, err)
+	}
+	return one.handle.LinkDel(l)
+}
+
+// RunCommand applies "tc qdisc replace dev .. root netem .." and "ip route replace .. via .." over netlink,
+// anything else is executed inside the namespace of the node and left running in the background
+func (b *Backend) RunCommand(nodeName string, command string) error {
+	n, err := b.node(nodeName)
+	if err != nil {
+		return err
+	}
+	fields := strings.Split(command, " ")
+	if ifname, attrs, ok, err := parseNetem(fields); ok {
+		if err != nil {
+			return fmt.Errorf("%s: %q: %w", nodeName, command, err)
+		}
+		return n.replaceNetem(ifname, attrs)
+	}
+	if route, ok, err := parseRoute(fields); ok {
+		if err != nil {
+			return fmt.Errorf("%s: %q: %w", nodeName, command, err)
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if route.Gw == nil {
+			return n.handle.RouteDel(route)
+		}
+		return n.handle.RouteReplace(route)
+	}
+	return b.start(nodeName, n, fields)
+}
+
+func (n *node) replaceNetem(ifname string, attrs netlink.NetemQdiscAttrs) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	l, err := n.handle.LinkByName(ifname)
+	if err != nil {
+		return err
+	}
+	qdisc := netlink.NewNetem(netlink.QdiscAttrs{
+		LinkIndex: l.Attrs().Index,
+		Handle:    netlink.MakeHandle(1, 0),
+		Parent:    netlink.HANDLE_ROOT,
+	}, attrs)
+	return n.handle.QdiscReplace(qdisc)
+}
+
+// RunBatch runs every line as tool command. There is no exec session to save with netlink,
+// like "-force -batch" it keeps going after a failing line and returns the first error.
+func (b *Backend) RunBatch(nodeName string, tool string, lines []string) error {
+	var first error
+	failed := 0
+	for _, line := range lines {
+		if err := b.RunCommand(nodeName, tool+" "+line); err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	if failed > 1 {
+		return fmt.Errorf("%d of %d batched commands failed, first: %w", failed, len(lines), first)
+	}
+	return first
+}
+
+// start runs a command in the namespace of the node, a process forked from a thread inherits its namespace
+func (b *Backend) start(nodeName string, n *node, fields []string) error {
+	cmd := exec.Command(fields[0], fields[1:]...)
+	err := inNamespace(n.ns, cmd.Start)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.processes = append(b.processes, cmd)
+	b.mu.Unlock()
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Warn().Err(err).Str("node", nodeName).Strs("command", fields).Msg("command exited")
+		}
+	}()
+	return nil
+}
+
+// Cleanup stops the processes started by RunCommand and deletes every namespace with the P7- prefix,
+// the veth pairs are deleted along with their namespaces
+func (b *Backend) Cleanup() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, cmd := range b.processes {
+		cmd.Process.Kill()
+	}
+	b.processes = nil
+	for _, n := range b.nodes {
+		n.handle.Close()
+		n.ns.Close()
+	}
+	b.nodes = make(map[string]*node)
+
+	entries, err := os.ReadDir(namespaceDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), namespacePrefix) {
+			continue
+		}
+		log.Info().Str("name", entry.Name()).Msg("removing namespace...")
+		if err := netns.DeleteNamed(entry.Name()); err != nil {
+			log.Error().Err(err).Str("path", filepath.Join(namespaceDir, entry.Name())).Msg("Error removing namespace")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package netns
+
+import (
+	"project/backend"
+	"testing"
+)
+
+func TestLink(t *testing.T) {
+	b := New()
+	if err := b.Init(); err != nil {
+		t.Skip(err)
+	}
+	defer b.Cleanup()
+	if _, err := b.CreateNode("P7test1", backend.Satellite); err != nil {
+		t.Skip(err)
+	}
+	if _, err := b.CreateNode("P7test2", backend.Satellite); err != nil {
+		t.Fatal(err)
+	}
+	link := backend.LinkDetails{
+		NetworkName: "P7-link-1",
+		Subnet:      "192.168.0.0/29",
+		NodeOneId:   "P7test1",
+		NodeOneIP:   "192.168.0.2",
+		NodeTwoId:   "P7test2",
+		NodeTwoIP:   "192.168.0.3",
+	}
+	if err := b.SetupLink(link); err != nil {
+		t.Fatal(err)
+	}
+	// the interfaces of the link already exist, the second pair must not be left behind
+	if err := b.SetupLink(link); err == nil {
+		t.Error("a link set up twice was accepted")
+	}
+	two, err := b.node("P7test2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := two.handle.LinkByName("p7v2b"); err == nil {
+		t.Error("the veth pair of the failed link was left behind")
+	}
+	// the next hop is only reachable if the interface named after the peer is up with its address
+	if err := b.RunCommand("P7test1", "ip route replace 10.0.0.1 via 192.168.0.3"); err != nil {
+		t.Error(err)
+	}
+	if err := b.TearDownLink(link); err != nil {
+		t.Error(err)
+	}
+	if err := b.RunCommand("P7test1", "ip route replace 10.0.0.1 via 192.168.0.3"); err == nil {
+		t.Error("route via a torn down link was accepted")
+	}
+}
@@ -0,0 +1,34 @@
+package podman
+
+import (
+	"context"
+	"project/backend"
+
+	"github.com/containers/podman/v4/pkg/bindings/containers"
+)
+
+// Backend runs every node as a podman container and every link as a podman network
+type Backend struct {
+	ctx    context.Context // holds the connection to the podman socket, set by Init
+	socket string
+}
+
+var _ backend.Backend = (*Backend)(nil)
+var _ backend.Batcher = (*Backend)(nil)
+
+func (b *Backend) CreateNode(name string, kind backend.NodeKind) (string, error) {
+	if kind == backend.GroundStation {
+		return b.CreateRunContainer(name, true, GroundStationRawImage)
+	}
+	return b.CreateRunContainer(name, false, SatelliteRawImage)
+}
+
+func (b *Backend) RemoveNode(name string) error {
+	timeout := uint(1)
+	ignore := true
+	if err := containers.Stop(b.ctx, name, &containers.StopOptions{Ignore: &ignore, Timeout: &timeout}); err != nil {
+		return err
+	}
+	_, err := containers.Remove(b.ctx, name, &containers.RemoveOptions{})
+	return err
+}
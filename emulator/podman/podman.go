@@ -1,10 +1,12 @@
 package podman
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
 	"os"
+	"project/backend"
 	"strings"
 	"sync"
 
@@ -25,7 +27,6 @@ import (
 const printOn bool = true
 
 var (
-	ctx context.Context
 	//SatelliteRawImage = "registry.hub.docker.com/mulvadt/satellite:latest"
 	SatelliteRawImage = "docker.io/mulvadt/satellite:latest"
 	//GroundStationRawImage = "registry.hub.docker.com/mulvadt/groundstation:latest"
@@ -37,7 +38,8 @@ var (
 
 )
 
-func InitPodman() {
+// Init connects to the podman socket of the user, or of the system without XDG_RUNTIME_DIR
+func (b *Backend) Init() error {
 	log.Info().Msg("Podman is now initializing")
 
 	// Get Podman socket location
@@ -48,12 +50,11 @@ func InitPodman() {
 	socket := "unix:" + sock_dir + "/podman/podman.sock"
 
 	// Connect to Podman socket
-	contex, err := bindings.NewConnection(context.Background(), socket)
+	ctx, err := bindings.NewConnection(context.Background(), socket)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
-	ctx = contex
+	b.ctx, b.socket = ctx, socket
 
 	if os.Getenv("FORCE_PULL") == "TRUE" {
 		_, err = images.Pull(ctx, SatelliteRawImage, &images.PullOptions{})
@@ -66,15 +67,15 @@ func InitPodman() {
 			log.Err(err).Msg(err.Error())
 		}
 	}
-
+	return nil
 }
 
-func ListContainers() {
+func (b *Backend) ListContainers() error {
 	// List images
-	imageSummary, err := images.List(ctx, &images.ListOptions{})
+	imageSummary, err := images.List(b.ctx, &images.ListOptions{})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to list images")
-		os.Exit(1)
+		return err
 	}
 	var names []string
 	for _, i := range imageSummary {
@@ -84,17 +85,19 @@ func ListContainers() {
 
 	// Container list
 	var latestContainers = 1
-	containerLatestList, err := containers.List(ctx, &containers.ListOptions{
+	containerLatestList, err := containers.List(b.ctx, &containers.ListOptions{
 		Last: &latestContainers,
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to list CONTAINERS")
-		os.Exit(1)
+		return err
 	}
 	log.Info().Int("Containers", len(containerLatestList)).Strs("names", containerLatestList[0].Names).Msg("List of containers")
+	return nil
 }
 
-func CreateRunContainer(containerName string, usetty bool, rawImage string) string {
+// CreateRunContainer creates and starts a container of rawImage named containerName and waits until it runs
+func (b *Backend) CreateRunContainer(containerName string, usetty bool, rawImage string) (string, error) {
 	// Container create
 	var capadd []string
 	capadd = append(capadd, "NET_ADMIN")
@@ -106,51 +109,43 @@ func CreateRunContainer(containerName string, usetty bool, rawImage string) stri
 	// 	// NSMode: specgen.NoNetwork,
 	// 	NSMode: specgen.Private,
 	// }
-	r, err := containers.CreateWithSpec(ctx, s, &containers.CreateOptions{})
+	r, err := containers.CreateWithSpec(b.ctx, s, &containers.CreateOptions{})
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create container")
-		// os.Exit(1)
+		return "", fmt.Errorf("creating container %s: %w", containerName, err)
 	}
 
-	err = containers.Rename(ctx, r.ID, &containers.RenameOptions{Name: &containerName})
+	err = containers.Rename(b.ctx, r.ID, &containers.RenameOptions{Name: &containerName})
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to name container")
+		return "", fmt.Errorf("naming container %s: %w", containerName, err)
 	}
 
 	// Container start
 	log.Info().Str("Name", containerName).Msg("Starting sattelite container...")
-	err = containers.Start(ctx, r.ID, &containers.StartOptions{})
+	err = containers.Start(b.ctx, r.ID, &containers.StartOptions{})
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to start container")
-
+		return "", fmt.Errorf("starting container %s: %w", containerName, err)
 	}
 
-	_, err = containers.Wait(ctx, r.ID, &containers.WaitOptions{
+	_, err = containers.Wait(b.ctx, r.ID, &containers.WaitOptions{
 		Condition: []define.ContainerStatus{define.ContainerStateRunning},
 	})
 	if err != nil {
-		fmt.Println(err)
+		return "", fmt.Errorf("waiting for container %s: %w", containerName, err)
 	}
-	network.Disconnect(ctx, "podman", containerName, &network.DisconnectOptions{})
-	return containerName
+	network.Disconnect(b.ctx, "podman", containerName, &network.DisconnectOptions{})
+	return containerName, nil
 }
 
-type LinkDetails struct {
-	NetworkName string `parquet:"network_name"`
-	Subnet      string `parquet:"subnet"`
-	NodeOneId   string `parquet:"node_one_id"`
-	NodeOneIP   string `parquet:"node_one_ip"`
-	NodeTwoId   string `parquet:"node_two_id"`
-	NodeTwoIP   string `parquet:"node_two_ip"`
-}
+// LinkDetails is kept so existing callers of the podman package keep compiling
+type LinkDetails = backend.LinkDetails
 
-func SetupLink(linkDetails LinkDetails) {
+func (b *Backend) SetupLink(linkDetails LinkDetails) error {
 	var subnets []types.Subnet
 	// log.Debug().Str("subnet", linkDetails.Subnet).Msg("Subnet string")
 	ip, ipnet, err := net.ParseCIDR(linkDetails.Subnet)
 	if err != nil {
 		log.Error().Err(err).Interface("linkDetails", linkDetails).Msg("Failed to parse subnet")
-		return
+		return err
 	}
 
 	subnets = append(subnets, types.Subnet{
@@ -166,89 +161,127 @@ func SetupLink(linkDetails LinkDetails) {
 
 	networkSettings := types.Network{Name: linkDetails.NetworkName, Subnets: subnets, Internal: true}
 
-	cn, err := network.Create(ctx, &networkSettings)
+	cn, err := network.Create(b.ctx, &networkSettings)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create network")
-		return
+		return err
 	}
 
 	if printOn {
 		log.Debug().Interface("cn", cn.Name).Msg("Added Network")
 	}
 
-	network.Disconnect(ctx, "podman", linkDetails.NodeOneId, &network.DisconnectOptions{})
-	network.Disconnect(ctx, "podman", linkDetails.NodeTwoId, &network.DisconnectOptions{})
-
-	connectContainerToNetwork(cn.Name, linkDetails.NodeOneIP, linkDetails.NodeOneId, linkDetails.NodeTwoId)
-	connectContainerToNetwork(cn.Name, linkDetails.NodeTwoIP, linkDetails.NodeTwoId, linkDetails.NodeOneId)
+	network.Disconnect(b.ctx, "podman", linkDetails.NodeOneId, &network.DisconnectOptions{})
+	network.Disconnect(b.ctx, "podman", linkDetails.NodeTwoId, &network.DisconnectOptions{})
 
+	if err := b.connectContainerToNetwork(cn.Name, linkDetails.NodeOneIP, linkDetails.NodeOneId, linkDetails.NodeTwoId); err != nil {
+		return err
+	}
+	return b.connectContainerToNetwork(cn.Name, linkDetails.NodeTwoIP, linkDetails.NodeTwoId, linkDetails.NodeOneId)
 }
 
-func TearDownLink(linkDetails LinkDetails) {
+func (b *Backend) TearDownLink(linkDetails LinkDetails) error {
 	//time.Sleep(5 * time.Second) // if aggregated buffer size is 50MB it takes 4 seconds to empty
 	// var forceSetting bool = false
 	// var timeoutSetting uint = 0
-	err := network.Disconnect(ctx, linkDetails.NetworkName, linkDetails.NodeOneId, &network.DisconnectOptions{})
+	err := network.Disconnect(b.ctx, linkDetails.NetworkName, linkDetails.NodeOneId, &network.DisconnectOptions{})
 	if err != nil {
-		log.Fatal().Str("containerName", linkDetails.NodeOneId).Str("networkName", linkDetails.NetworkName).Err(err).Msg("failed to disconnect container from network")
+		log.Error().Str("containerName", linkDetails.NodeOneId).Str("networkName", linkDetails.NetworkName).Err(err).Msg("failed to disconnect container from network")
+		return err
 	}
-	err = network.Disconnect(ctx, linkDetails.NetworkName, linkDetails.NodeTwoId, &network.DisconnectOptions{})
+	err = network.Disconnect(b.ctx, linkDetails.NetworkName, linkDetails.NodeTwoId, &network.DisconnectOptions{})
 	if err != nil {
-		log.Fatal().Str("containerName", linkDetails.NodeTwoId).Str("networkName", linkDetails.NetworkName).Err(err).Msg("failed to disconnect container from network")
+		log.Error().Str("containerName", linkDetails.NodeTwoId).Str("networkName", linkDetails.NetworkName).Err(err).Msg("failed to disconnect container from network")
+		return err
 	}
-	report, err := network.Remove(ctx, linkDetails.NetworkName, &network.RemoveOptions{
+	report, err := network.Remove(b.ctx, linkDetails.NetworkName, &network.RemoveOptions{
 		// Force:   &forceSetting,
 		// Timeout: &timeoutSetting,
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to remove network")
-		return
+		return err
 	}
 	if printOn {
 		log.Debug().Interface("report", report).Msg("Removed Network")
 	}
+	return nil
 }
 
-func connectContainerToNetwork(cnname string, cip string, cid string, ifname string) {
+func (b *Backend) connectContainerToNetwork(cnname string, cip string, cid string, ifname string) error {
 	var ip []net.IP
 	ip = append(ip, net.ParseIP(cip))
 	// log.Debug().Str("cip", cip).Str("cid", cid).Msg("Connecting to network")
-	err := network.Connect(ctx, cnname, cid, &types.PerNetworkOptions{StaticIPs: ip, InterfaceName: ifname})
+	err := network.Connect(b.ctx, cnname, cid, &types.PerNetworkOptions{StaticIPs: ip, InterfaceName: ifname})
 	if err != nil {
 		log.Error().Str("ifname", ifname).Err(err).Msg("Failed to connect network")
-		return
+		return err
 	}
+	return nil
 }
 
-func RunCommand(nodeID string, command string) error {
-	cmd := strings.Split(command, " ")
+func (b *Backend) RunCommand(nodeID string, command string) error {
+	return b.runCommand(nodeID, strings.Split(command, " "))
+}
+
+// RunBatch runs all lines with one "tool -batch" exec session in the container
+func (b *Backend) RunBatch(nodeID string, tool string, lines []string) error {
+	return b.runCommand(nodeID, backend.BatchScript(tool, lines))
+}
+
+// output collects what a command writes, so it can be reported when the command fails
+type output struct {
+	bytes.Buffer
+}
+
+func (o *output) Close() error {
+	return nil
+}
+
+// runCommand runs cmd in the container and waits for it to exit, a non zero exit code is an error
+func (b *Backend) runCommand(nodeID string, cmd []string) error {
 	// ctx is connection to podman socket
 	// create execution session in container with nodeID
-	execId, err := containers.ExecCreate(ctx, nodeID, &handlers.ExecCreateConfig{
+	execId, err := containers.ExecCreate(b.ctx, nodeID, &handlers.ExecCreateConfig{
 		ExecConfig: dockertypes.ExecConfig{
-			Privileged: true,   // container is in privileged mode
-			User:       "root", // root is gonna run the command
-			Cmd:        cmd,
+			Privileged:   true,   // container is in privileged mode
+			User:         "root", // root is gonna run the command
+			AttachStdout: true,
+			AttachStderr: true,
+			Cmd:          cmd,
 		},
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create exec command")
 		return err
 	}
-	// execute command in running container
-	err = containers.ExecStart(ctx, execId, &containers.ExecStartOptions{})
+	// attaching replaces the transport of the connection, every session gets a connection of its own
+	// so that commands in several containers can run at the same time
+	conn, err := bindings.NewConnection(context.Background(), b.socket)
 	if err != nil {
+		return err
+	}
+	out := &output{}
+	options := new(containers.ExecStartAndAttachOptions).WithAttachOutput(true).WithOutputStream(out).WithAttachError(true).WithErrorStream(out)
+	// execute command in running container
+	if err := containers.ExecStartAndAttach(conn, execId, options); err != nil {
 		log.Error().Err(err).Msg("Failed to start exec command")
 		return err
 	}
+	session, err := containers.ExecInspect(b.ctx, execId, nil)
+	if err != nil {
+		return err
+	}
+	if session.ExitCode != 0 {
+		return fmt.Errorf("%s in %s exited with %d: %s", cmd[0], nodeID, session.ExitCode, strings.TrimSpace(out.String()))
+	}
 	return nil
 }
 
-func Cleanup() {
-	containerLatestList, err := containers.List(ctx, &containers.ListOptions{})
+func (b *Backend) Cleanup() error {
+	containerLatestList, err := containers.List(b.ctx, &containers.ListOptions{})
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 	// log.Debug().Str("name", containerLatestList[0].Names[0]).Msg("Latest container is")
 
@@ -274,19 +307,19 @@ func Cleanup() {
 				if err != nil {
 					log.Error().Err(err).Msg("Error removing container")
 				}
-			}(ctx, c.ID)
+			}(b.ctx, c.ID)
 		}
 	}
 	wg.Wait()
 
-	networks, err := network.List(ctx, &network.ListOptions{})
+	networks, err := network.List(b.ctx, &network.ListOptions{})
 	if err != nil {
 		log.Error().Err(err).Msg("Could not get list of networks")
 	}
 	for _, nw := range networks {
 		log.Info().Str("name", nw.Name).Msg("removing network...")
 		if strings.Contains(nw.Name, "P7") {
-			_, err := network.Remove(ctx, nw.ID, &network.RemoveOptions{Force: &forceBool, Timeout: &duration})
+			_, err := network.Remove(b.ctx, nw.ID, &network.RemoveOptions{Force: &forceBool, Timeout: &duration})
 			if err != nil {
 				log.Error().Err(err).Msg("Error removing network")
 
@@ -294,4 +327,5 @@ func Cleanup() {
 
 		}
 	}
+	return nil
 }
@@ -0,0 +1,73 @@
+package routing
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Prefix is a ground station subnet to route towards: the subnet of the link between an access point (Node)
+// and a user equipment ground station (Peer). Both ends are directly connected to it and get no route for it.
+type Prefix struct {
+	Subnet string
+	Node   int
+	Peer   int
+}
+
+// ForwardingTables holds the next hop address of every node towards every prefix: tables[node][subnet] = next hop IP
+type ForwardingTables map[int]map[string]string
+
+// nextHopIP returns the address of next on its link to node
+func nextHopIP(node, next int) string {
+	linkid, swapped := linkNameFromNodeId(node, next)
+	link := LINKS[linkid]
+	if swapped {
+		return link.NodeTwoIP
+	}
+	return link.NodeOneIP
+}
+
+// NetworkForwardingTables builds the tables of all nodes from the next hops towards every prefix node,
+// nextHops[node] being the result of graph.NextHops for that node
+func NetworkForwardingTables(prefixes []Prefix, nextHops map[int][]int) ForwardingTables {
+	tables := make(ForwardingTables)
+	for _, prefix := range prefixes {
+		for node, next := range nextHops[prefix.Node] {
+			if next == -1 || node == prefix.Node || node == prefix.Peer {
+				continue
+			}
+			if tables[node] == nil {
+				tables[node] = make(map[string]string)
+			}
+			tables[node][prefix.Subnet] = nextHopIP(node, next)
+		}
+	}
+	return tables
+}
+
+// Diff returns the commands that turn the tables into next, per node.
+// Routes that changed or are new are replaced, routes to prefixes a node can no longer reach are deleted.
+func (tables ForwardingTables) Diff(next ForwardingTables) map[int][]string {
+	commands := make(map[int][]string)
+	for node, table := range next {
+		for subnet, nexthopIP := range table {
+			if tables[node][subnet] != nexthopIP {
+				commands[node] = append(commands[node], ipRouteVia(subnet, nexthopIP))
+			}
+		}
+	}
+	for node, table := range tables {
+		for subnet := range table {
+			if _, ok := next[node][subnet]; !ok {
+				commands[node] = append(commands[node], ipRouteDel(subnet))
+			}
+		}
+	}
+	for node := range commands {
+		sort.Strings(commands[node])
+	}
+	return commands
+}
+
+func ipRouteDel(destinationIP string) string {
+	return fmt.Sprintf("ip route del %s", destinationIP)
+}
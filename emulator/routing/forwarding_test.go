@@ -0,0 +1,40 @@
+package routing
+
+import (
+	"project/backend"
+	"testing"
+)
+
+func TestForwardingTablesDiff(t *testing.T) {
+	// satellites 0 and 1, access point 2 with user equipment 3
+	LINKS = map[string]backend.LinkDetails{
+		"S0-S1": {NodeOneIP: "10.0.0.2", NodeTwoIP: "10.0.0.3"},
+		"S0-S2": {NodeOneIP: "10.0.0.10", NodeTwoIP: "10.0.0.11"},
+		"S1-S2": {NodeOneIP: "10.0.0.18", NodeTwoIP: "10.0.0.19"},
+		"S2-S3": {NodeOneIP: "10.0.0.26", NodeTwoIP: "10.0.0.27", Subnet: "10.0.0.24/29"},
+	}
+	prefixes := []Prefix{{Subnet: "10.0.0.24/29", Node: 2, Peer: 3}}
+	before := NetworkForwardingTables(prefixes, map[int][]int{2: {2, 0, -1, 2}})
+	if before[1]["10.0.0.24/29"] != "10.0.0.3" || before[0]["10.0.0.24/29"] != "10.0.0.10" {
+		t.Errorf("wrong tables %v", before)
+	}
+	if _, ok := before[3]; ok {
+		t.Error("the user equipment is connected to its own prefix")
+	}
+
+	// satellite 1 now reaches the access point directly and satellite 0 lost it
+	after := NetworkForwardingTables(prefixes, map[int][]int{2: {-1, 2, -1, 2}})
+	commands := before.Diff(after)
+	if len(commands) != 2 {
+		t.Errorf("expected commands for two nodes, got %v", commands)
+	}
+	if len(commands[0]) != 1 || commands[0][0] != "ip route del 10.0.0.24/29" {
+		t.Errorf("wrong commands for node 0: %v", commands[0])
+	}
+	if len(commands[1]) != 1 || commands[1][0] != "ip route replace 10.0.0.24/29 via 10.0.0.18" {
+		t.Errorf("wrong commands for node 1: %v", commands[1])
+	}
+	if len(after.Diff(after)) != 0 {
+		t.Error("equal tables must not produce commands")
+	}
+}
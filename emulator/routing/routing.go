@@ -2,14 +2,14 @@ package routing
 
 import (
 	"fmt"
-	"project/podman"
+	"project/backend"
 	"strconv"
 
 	"github.com/rs/zerolog/log"
 )
 
 var (
-	LINKS map[string]podman.LinkDetails
+	LINKS map[string]backend.LinkDetails
 )
 
 const printOn bool = false
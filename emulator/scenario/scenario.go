@@ -0,0 +1,668 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"project/space"
+	"project/tle"
+	"project/walker"
+	"sort"
+	"strings"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the scenario file format understood by this build of the emulator
+const Version = 1
+
+// MinStep is the shortest time step, positions are propagated and routes updated at most this often
+const MinStep = Duration(10 * time.Millisecond)
+
+// Scenario describes everything needed to reproduce one emulator run
+type Scenario struct {
+	Version        int           `json:"version" yaml:"version"`
+	Name           string        `json:"name" yaml:"name"`
+	Constellation  Constellation `json:"constellation" yaml:"constellation"`
+	GroundStations string        `json:"groundstations" yaml:"groundstations"`
+	Connections    []Connection  `json:"connections" yaml:"connections"`
+	Time           Time          `json:"time" yaml:"time"`
+	Links          Links         `json:"links" yaml:"links"`
+	Netem          Netem         `json:"netem" yaml:"netem"`
+	Policy         Policy        `json:"policy" yaml:"policy"`
+	Routing        string        `json:"routing,omitempty" yaml:"routing,omitempty"`
+	Execution      Execution     `json:"execution,omitempty" yaml:"execution,omitempty"`
+	Commands       []Command     `json:"commands,omitempty" yaml:"commands,omitempty"`
+	OutputDir      string        `json:"output_dir" yaml:"output_dir"`
+}
+
+// Constellation selects where satellite positions come from.
+// Source "tle" propagates TLEFile, two line elements or OMM in JSON, XML or KVN, source "parquet" loads the positions generated by satellite_positions.py,
+// source "walker" generates the Walker constellation of the Walker parameters or, without them, the preset called Name,
+// source "catalog" propagates the element sets the Catalog picks.
+// Propagator picks the orbit model of the tle and walker sources: "sgp4" (the default for tle), "kepler" (the default for walker) or "j2".
+// With History set TLEFile is a file or a directory of files with any number of element sets per satellite,
+// every step is propagated from the set of the satellite with the epoch closest to it.
+// With CacheDir set the propagated positions of the tle and catalog sources are cached there and reused by later runs.
+// Without it the tle, catalog and walker sources are propagated while the run goes, Window steps at a time (space.DefaultWindow if 0).
+type Constellation struct {
+	Name                       string                `json:"name" yaml:"name"`
+	Source                     string                `json:"source" yaml:"source"`
+	TLEFile                    string                `json:"tle_file,omitempty" yaml:"tle_file,omitempty"`
+	PositionsFile              string                `json:"positions_file,omitempty" yaml:"positions_file,omitempty"`
+	GroundStationPositionsFile string                `json:"groundstation_positions_file,omitempty" yaml:"groundstation_positions_file,omitempty"`
+	Walker                     *walker.Constellation `json:"walker,omitempty" yaml:"walker,omitempty"`
+	Catalog                    *Catalog              `json:"catalog,omitempty" yaml:"catalog,omitempty"`
+	Propagator                 string                `json:"propagator,omitempty" yaml:"propagator,omitempty"`
+	History                    bool                  `json:"history,omitempty" yaml:"history,omitempty"`
+	CacheDir                   string                `json:"cache_dir,omitempty" yaml:"cache_dir,omitempty"`
+	Window                     int                   `json:"window,omitempty" yaml:"window,omitempty"`
+}
+
+// Model returns the propagation model of the constellation
+func (c Constellation) Model() string {
+	if c.Propagator != "" {
+		return c.Propagator
+	}
+	if c.Source == SourceWalker {
+		return space.ModelKepler
+	}
+	return space.ModelSGP4
+}
+
+// WalkerConstellation returns the Walker parameters of the constellation, or the preset called Name if there are none
+func (c Constellation) WalkerConstellation() (walker.Constellation, error) {
+	if c.Walker != nil {
+		return *c.Walker, nil
+	}
+	return walker.Preset(c.Name)
+}
+
+// Catalog picks the satellites of Group and IDs, by NORAD catalogue number, and the latest element set of each before
+// the start of the run. The sets come from the offline catalogue in Dir, imported with cmd/catalog, or without Dir from
+// Space-Track with the credentials in SPACETRACK_USER and SPACETRACK_PASS. Groups are only known to catalogues.
+type Catalog struct {
+	Dir   string `json:"dir,omitempty" yaml:"dir,omitempty"`
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+	IDs   []int  `json:"ids,omitempty" yaml:"ids,omitempty"`
+}
+
+// Client returns the client the element sets are queried from, the catalogue in Dir or Space-Track
+func (c Catalog) Client() (tle.Client, error) {
+	if c.Dir == "" {
+		return gosat.NewSpacetrack(os.Getenv("SPACETRACK_USER"), os.Getenv("SPACETRACK_PASS")), nil
+	}
+	return tle.OpenCatalog(c.Dir)
+}
+
+// ElementSets returns the NORAD catalogue numbers of the satellites, sorted, and their latest element sets before at
+func (c Catalog) ElementSets(at time.Time) (ids []int, sets []gosat.Satellite, err error) {
+	client, err := c.Client()
+	if err != nil {
+		return nil, nil, err
+	}
+	ids = append(ids, c.IDs...)
+	if c.Group != "" {
+		catalog, ok := client.(*tle.Catalog)
+		if !ok {
+			return nil, nil, fmt.Errorf("group %q needs a catalogue directory", c.Group)
+		}
+		members, err := catalog.Group(c.Group)
+		if err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, members...)
+	}
+	sort.Ints(ids)
+	unique := ids[:0]
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	ids = unique
+	if sets, err = tle.GetTLEfromNoradIDs(client, ids, at); err != nil {
+		return nil, nil, err
+	}
+	return ids, sets, nil
+}
+
+// Connection is a pair of ground station titles that exchange traffic
+type Connection struct {
+	Source      string `json:"source" yaml:"source"`
+	Destination string `json:"destination" yaml:"destination"`
+}
+
+type Time struct {
+	Start    time.Time `json:"start" yaml:"start"`
+	Step     Duration  `json:"step" yaml:"step"`
+	Duration Duration  `json:"duration" yaml:"duration"`
+}
+
+// Links sets the range of links and the topology of the inter-satellite links.
+// Topology "range" links every pair of satellites in range, "grid" is the +Grid of two links in the plane
+// and two to the neighbouring planes, "greedy" gives each satellite Terminals links assigned shortest first,
+// keeping the links of the previous step unless a new link is shorter by more than the Hysteresis fraction.
+// Inter-satellite links whose relative speed or Doppler shift exceed MaxRelativeSpeed or MaxDoppler can not be tracked and stay down,
+// as do links whose line of sight passes below GrazingHeight (80 km if not set).
+// Optical links whose terminals look closer to the sun than SunExclusion (inter-satellite) or GroundSunExclusion
+// (between access points and satellites) degrees are dazzled and stay down as well.
+type Links struct {
+	MaxFSODistance     float64  `json:"max_fso_distance" yaml:"max_fso_distance"`     // km
+	AccessPointRange   float64  `json:"access_point_range" yaml:"access_point_range"` // km
+	Topology           string   `json:"topology,omitempty" yaml:"topology,omitempty"`
+	Terminals          int      `json:"terminals,omitempty" yaml:"terminals,omitempty"`
+	Hysteresis         float64  `json:"hysteresis,omitempty" yaml:"hysteresis,omitempty"`
+	MaxRelativeSpeed   float64  `json:"max_relative_speed,omitempty" yaml:"max_relative_speed,omitempty"`     // km/s, 0 for no limit
+	MaxDoppler         float64  `json:"max_doppler,omitempty" yaml:"max_doppler,omitempty"`                   // GHz, 0 for no limit
+	GrazingHeight      *float64 `json:"grazing_height,omitempty" yaml:"grazing_height,omitempty"`             // km
+	SunExclusion       float64  `json:"sun_exclusion,omitempty" yaml:"sun_exclusion,omitempty"`               // degrees, 0 for no limit
+	GroundSunExclusion float64  `json:"ground_sun_exclusion,omitempty" yaml:"ground_sun_exclusion,omitempty"` // degrees, 0 for no limit
+}
+
+// Grazing returns the lowest altitude in km the line of sight of an inter-satellite link may pass
+func (l Links) Grazing() float64 {
+	if l.GrazingHeight != nil {
+		return *l.GrazingHeight
+	}
+	return space.DefaultGrazingHeight
+}
+
+// Netem holds the parameters appended to every "tc qdisc replace ... netem" command
+type Netem struct {
+	Rate  string `json:"rate" yaml:"rate"`
+	Limit int    `json:"limit" yaml:"limit"`
+}
+
+// Policy selects how the engine decides when routes are recomputed.
+// L2Interval is how often netem delays are refreshed, L3Interval how often "periodic" and "nodrop" recompute the path.
+// NetemInterval, if set, refreshes the delays this often between the steps from interpolated positions; it needs l2_interval equal to time.step.
+// ChangeFile is a route change file (as written by a previous run) for "shortest-path",
+// Lookahead the number of steps "predicted-break" looks ahead for a link on the path breaking.
+type Policy struct {
+	Name          string   `json:"name" yaml:"name"`
+	L2Interval    Duration `json:"l2_interval" yaml:"l2_interval"`
+	L3Interval    Duration `json:"l3_interval,omitempty" yaml:"l3_interval,omitempty"`
+	NetemInterval Duration `json:"netem_interval,omitempty" yaml:"netem_interval,omitempty"`
+	ChangeFile    string   `json:"change_file,omitempty" yaml:"change_file,omitempty"`
+	Lookahead     int      `json:"lookahead,omitempty" yaml:"lookahead,omitempty"`
+}
+
+// Execution tunes how the commands of a step are run in the nodes.
+// Concurrency is the number of nodes commands are run in at the same time, 0 uses the engine default.
+type Execution struct {
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+}
+
+// Command is run once inside Node when the emulation reaches At, e.g. to start iperf3
+type Command struct {
+	At      Duration `json:"at" yaml:"at"`
+	Node    string   `json:"node" yaml:"node"`
+	Command string   `json:"command" yaml:"command"`
+}
+
+const (
+	SourceTLE     = "tle"
+	SourceParquet = "parquet"
+	SourceWalker  = "walker"
+	SourceCatalog = "catalog"
+)
+
+const (
+	PolicyPeriodic       = "periodic"
+	PolicyShortestPath   = "shortest-path"
+	PolicyPredictedBreak = "predicted-break"
+	PolicyNoDrop         = "nodrop"
+)
+
+var policies = []string{PolicyPeriodic, PolicyShortestPath, PolicyPredictedBreak, PolicyNoDrop}
+
+const (
+	TopologyRange  = "range"
+	TopologyGrid   = "grid"
+	TopologyGreedy = "greedy"
+)
+
+var topologies = []string{TopologyRange, TopologyGrid, TopologyGreedy}
+
+// Routing modes: "path" installs routes along the path of every connection only,
+// "network" installs forwarding tables in every node towards every ground station prefix
+const (
+	RoutingPath    = "path"
+	RoutingNetwork = "network"
+)
+
+var constellations = []string{"Kepler", "OneWeb", "Starlink"}
+
+// Default returns the scenario the emulator used to have hard coded
+func Default() Scenario {
+	return Scenario{
+		Version: Version,
+		Name:    "oneweb-elalamo-koto",
+		Constellation: Constellation{
+			Name:          "OneWeb",
+			Source:        SourceTLE,
+			TLEFile:       "./OneWeb",
+			PositionsFile: "./constellation.parquet",
+		},
+		GroundStations: "./groundstations.txt",
+		Connections:    []Connection{{Source: "ElAlamo", Destination: "Koto"}},
+		Time: Time{
+			Start:    time.Date(2022, 9, 11, 12, 00, 00, 00, time.UTC),
+			Step:     Duration(15 * time.Second),
+			Duration: Duration(time.Date(2022, 9, 21, 22, 00, 00, 00, time.UTC).Sub(time.Date(2022, 9, 11, 12, 00, 00, 00, time.UTC))),
+		},
+		Links: Links{
+			MaxFSODistance:   3000,
+			AccessPointRange: 8.0,
+		},
+		Netem: Netem{
+			Rate:  "100mbit",
+			Limit: 500,
+		},
+		Policy: Policy{
+			Name:       PolicyPeriodic,
+			L2Interval: Duration(15 * time.Second),
+			L3Interval: Duration(30 * time.Second),
+		},
+		OutputDir: "./runs",
+	}
+}
+
+// Steps is the number of time steps covered by the scenario
+func (s Scenario) Steps() int {
+	if s.Time.Step <= 0 {
+		return 0
+	}
+	return int(s.Time.Duration / s.Time.Step)
+}
+
+// Load reads a scenario from a .json, .yaml or .yml file and validates it.
+// Unknown fields are rejected so a misspelled parameter does not silently fall back to zero.
+func Load(path string) (Scenario, error) {
+	var s Scenario
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&s)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&s)
+	default:
+		return s, fmt.Errorf("unsupported scenario file extension %q (use .json, .yaml or .yml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed parsing scenario %s: %w", path, err)
+	}
+	// files without a policy section keep the behaviour cmd/emulator always had:
+	// netem every step and a new path every second step
+	if s.Policy == (Policy{}) {
+		s.Policy = Policy{Name: PolicyPeriodic, L2Interval: s.Time.Step, L3Interval: 2 * s.Time.Step}
+	}
+	if err = s.Validate(); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// Save writes the scenario to path, choosing the encoding from the file extension
+func (s Scenario) Save(path string) error {
+	var raw []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		raw, err = json.MarshalIndent(s, "", "  ")
+	case ".yaml", ".yml":
+		raw, err = yaml.Marshal(s)
+	default:
+		return fmt.Errorf("unsupported scenario file extension %q (use .json, .yaml or .yml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+// CreateRunDir makes a fresh directory for the outputs of one run below OutputDir
+// and stores a copy of the scenario in it, so the run can be reproduced from that directory alone
+func (s Scenario) CreateRunDir(now time.Time) (string, error) {
+	dir := filepath.Join(s.OutputDir, s.Name+"-"+now.UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := s.Save(filepath.Join(dir, "scenario.yaml")); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// ValidationError lists every problem found in a scenario, not just the first one
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid scenario:\n\t" + strings.Join(e.Problems, "\n\t")
+}
+
+// Validate checks the scenario before any data is loaded or containers are started
+func (s Scenario) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if s.Version != Version {
+		add("version: got %d, this emulator understands version %d", s.Version, Version)
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		add("name: must not be empty")
+	}
+
+	known := false
+	for _, name := range constellations {
+		if s.Constellation.Name == name {
+			known = true
+		}
+	}
+	if !known && !(s.Constellation.Source == SourceWalker && s.Constellation.Walker != nil) {
+		add("constellation.name: %q is not one of %s", s.Constellation.Name, strings.Join(constellations, ", "))
+	}
+	switch s.Constellation.Source {
+	case SourceTLE:
+		if s.Constellation.TLEFile == "" {
+			add("constellation.tle_file: required when source is %q", SourceTLE)
+		}
+	case SourceParquet:
+		if s.Constellation.PositionsFile == "" {
+			add("constellation.positions_file: required when source is %q", SourceParquet)
+		}
+		if s.Constellation.Propagator != "" {
+			add("constellation.propagator: positions loaded from %q are not propagated", SourceParquet)
+		}
+	case SourceWalker:
+		if s.Constellation.Model() == space.ModelSGP4 {
+			add("constellation.propagator: %q needs two line elements, walker constellations use %q or %q", space.ModelSGP4, space.ModelKepler, space.ModelJ2)
+		}
+		if s.Constellation.Walker != nil {
+			if err := s.Constellation.Walker.Validate(); err != nil {
+				add("constellation.walker: %v", err)
+			}
+		}
+	case SourceCatalog:
+		switch catalog := s.Constellation.Catalog; {
+		case catalog == nil:
+			add("constellation.catalog: required when source is %q", SourceCatalog)
+		case catalog.Group == "" && len(catalog.IDs) == 0:
+			add("constellation.catalog: a group or ids are required")
+		case catalog.Group != "" && catalog.Dir == "":
+			add("constellation.catalog.group: groups are only known to catalogues, dir is required")
+		}
+		if s.Constellation.Catalog != nil {
+			for i, id := range s.Constellation.Catalog.IDs {
+				if id <= 0 {
+					add("constellation.catalog.ids[%d]: %d is not a NORAD catalogue number", i, id)
+				}
+			}
+		}
+	default:
+		add("constellation.source: %q must be %q, %q, %q or %q", s.Constellation.Source, SourceTLE, SourceParquet, SourceWalker, SourceCatalog)
+	}
+
+	switch s.Constellation.Model() {
+	case space.ModelSGP4, space.ModelKepler, space.ModelJ2:
+	default:
+		add("constellation.propagator: %q is not one of %s", s.Constellation.Model(), strings.Join(space.Models, ", "))
+	}
+	if s.Constellation.History && s.Constellation.Source != SourceTLE {
+		add("constellation.history: only element sets of source %q have a history", SourceTLE)
+	}
+	if s.Constellation.Window < 0 {
+		add("constellation.window: must not be negative, got %d", s.Constellation.Window)
+	}
+
+	if s.GroundStations == "" && s.Constellation.GroundStationPositionsFile == "" {
+		add("groundstations: a ground station file or constellation.groundstation_positions_file is required")
+	}
+	if len(s.Connections) == 0 {
+		add("connections: at least one ground station pair is required")
+	}
+	for i, c := range s.Connections {
+		if c.Source == "" || c.Destination == "" {
+			add("connections[%d]: source and destination are required", i)
+		} else if c.Source == c.Destination {
+			add("connections[%d]: source and destination are both %q", i, c.Source)
+		}
+	}
+
+	if s.Time.Start.IsZero() {
+		add("time.start: required")
+	}
+	if s.Time.Step <= 0 {
+		add("time.step: must be positive, got %s", s.Time.Step)
+	} else if s.Time.Step < MinStep {
+		add("time.step: must be at least %s, got %s", MinStep, s.Time.Step)
+	}
+	if s.Time.Duration <= 0 {
+		add("time.duration: must be positive, got %s", s.Time.Duration)
+	} else if s.Time.Step > 0 && s.Time.Duration < 2*s.Time.Step {
+		add("time.duration: %s must cover at least two steps of %s", s.Time.Duration, s.Time.Step)
+	}
+
+	if s.Links.MaxFSODistance <= 0 {
+		add("links.max_fso_distance: must be positive, got %g", s.Links.MaxFSODistance)
+	}
+	if s.Links.AccessPointRange < 0 {
+		add("links.access_point_range: must not be negative, got %g", s.Links.AccessPointRange)
+	}
+	switch s.Links.Topology {
+	case "", TopologyRange, TopologyGrid:
+	case TopologyGreedy:
+		if s.Links.Terminals < 1 {
+			add("links.terminals: must be at least 1 for topology %q, got %d", s.Links.Topology, s.Links.Terminals)
+		}
+		if s.Links.Hysteresis < 0 || s.Links.Hysteresis >= 1 {
+			add("links.hysteresis: must be at least 0 and below 1, got %g", s.Links.Hysteresis)
+		}
+	default:
+		add("links.topology: %q is not one of %s", s.Links.Topology, strings.Join(topologies, ", "))
+	}
+	if s.Links.MaxRelativeSpeed < 0 {
+		add("links.max_relative_speed: must not be negative, got %g", s.Links.MaxRelativeSpeed)
+	}
+	if s.Links.MaxDoppler < 0 {
+		add("links.max_doppler: must not be negative, got %g", s.Links.MaxDoppler)
+	}
+	if s.Links.Grazing() < 0 {
+		add("links.grazing_height: must not be negative, got %g", s.Links.Grazing())
+	}
+	if s.Links.SunExclusion < 0 || s.Links.SunExclusion > 180 {
+		add("links.sun_exclusion: must be between 0 and 180 degrees, got %g", s.Links.SunExclusion)
+	}
+	if s.Links.GroundSunExclusion < 0 || s.Links.GroundSunExclusion > 180 {
+		add("links.ground_sun_exclusion: must be between 0 and 180 degrees, got %g", s.Links.GroundSunExclusion)
+	}
+
+	if strings.TrimSpace(s.Netem.Rate) == "" || strings.Contains(s.Netem.Rate, " ") {
+		add("netem.rate: %q must be a single tc rate such as 100mbit", s.Netem.Rate)
+	}
+	if s.Netem.Limit <= 0 {
+		add("netem.limit: must be positive, got %d", s.Netem.Limit)
+	}
+
+	s.validatePolicy(add)
+	if s.Execution.Concurrency < 0 {
+		add("execution.concurrency: must not be negative, got %d", s.Execution.Concurrency)
+	}
+	switch s.Routing {
+	case "", RoutingPath:
+	case RoutingNetwork:
+		if s.Policy.Name == PolicyNoDrop {
+			add("routing: %q can not be combined with policy %q", s.Routing, s.Policy.Name)
+		}
+	default:
+		add("routing: %q is not one of %s, %s", s.Routing, RoutingPath, RoutingNetwork)
+	}
+	for i, c := range s.Commands {
+		if c.Node == "" || strings.TrimSpace(c.Command) == "" {
+			add("commands[%d]: node and command are required", i)
+		}
+		if c.At < 0 || c.At > s.Time.Duration {
+			add("commands[%d].at: %s is outside the scenario duration", i, c.At)
+		}
+	}
+
+	if s.OutputDir == "" {
+		add("output_dir: required")
+	}
+
+	if len(problems) != 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (s Scenario) validatePolicy(add func(format string, args ...interface{})) {
+	p := s.Policy
+	known := false
+	for _, name := range policies {
+		if p.Name == name {
+			known = true
+		}
+	}
+	if !known {
+		add("policy.name: %q is not one of %s", p.Name, strings.Join(policies, ", "))
+	}
+	// intervals are counted in steps, so they have to line up with the step size
+	multipleOfStep := func(field string, d Duration) {
+		if s.Time.Step > 0 && (d <= 0 || d%s.Time.Step != 0) {
+			add("policy.%s: %s must be a positive multiple of time.step %s", field, d, s.Time.Step)
+		}
+	}
+	multipleOfStep("l2_interval", p.L2Interval)
+	if p.NetemInterval != 0 {
+		if p.NetemInterval < MinStep || s.Time.Step%p.NetemInterval != 0 {
+			add("policy.netem_interval: %s must be at least %s and divide time.step %s", p.NetemInterval, MinStep, s.Time.Step)
+		}
+		if p.L2Interval != s.Time.Step {
+			add("policy.netem_interval: requires l2_interval equal to time.step %s, got %s", s.Time.Step, p.L2Interval)
+		}
+	}
+	switch p.Name {
+	case PolicyPeriodic, PolicyNoDrop:
+		multipleOfStep("l3_interval", p.L3Interval)
+	case PolicyShortestPath:
+		if p.ChangeFile == "" {
+			add("policy.change_file: required for policy %q", p.Name)
+		}
+	case PolicyPredictedBreak:
+		if p.Lookahead < 1 {
+			add("policy.lookahead: must be at least one step, got %d", p.Lookahead)
+		}
+	}
+}
+
+// NetworkRouting reports whether the engine keeps network wide forwarding tables instead of per path routes
+func (s Scenario) NetworkRouting() bool {
+	return s.Routing == RoutingNetwork
+}
+
+// L2Steps is the number of steps between netem updates
+func (s Scenario) L2Steps() int {
+	return int(s.Policy.L2Interval / s.Time.Step)
+}
+
+// NetemUpdates is the number of netem updates per step, the one at the step included
+func (s Scenario) NetemUpdates() int {
+	if s.Policy.NetemInterval <= 0 {
+		return 1
+	}
+	return int(s.Time.Step / s.Policy.NetemInterval)
+}
+
+// L3Steps is the number of steps between periodic route updates
+func (s Scenario) L3Steps() int {
+	return int(s.Policy.L3Interval / s.Time.Step)
+}
+
+// CheckInputs verifies that every input file the scenario refers to can be opened
+func (s Scenario) CheckInputs() error {
+	var problems []string
+	files := map[string]string{
+		"groundstations": s.GroundStations,
+		"constellation.groundstation_positions_file": s.Constellation.GroundStationPositionsFile,
+	}
+	if s.Policy.Name == PolicyShortestPath {
+		files["policy.change_file"] = s.Policy.ChangeFile
+	}
+	switch s.Constellation.Source {
+	case SourceTLE:
+		files["constellation.tle_file"] = s.Constellation.TLEFile
+	case SourceParquet:
+		files["constellation.positions_file"] = s.Constellation.PositionsFile
+	case SourceCatalog:
+		if s.Constellation.Catalog != nil {
+			files["constellation.catalog.dir"] = s.Constellation.Catalog.Dir
+		}
+	}
+	for field, path := range files {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Duration is a time.Duration that reads and writes as "15s" instead of nanoseconds
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(raw []byte) error {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return errors.New("durations must be strings such as \"15s\"")
+	}
+	return d.parse(text)
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d *Duration) parse(text string) error {
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
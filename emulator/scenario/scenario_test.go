@@ -0,0 +1,350 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"project/space"
+	"project/tle"
+	"project/walker"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateListsAllProblems(t *testing.T) {
+	s := Default()
+	s.Version = 7
+	s.Time.Step = 0
+	s.Netem.Rate = "100 mbit"
+	err := s.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected *ValidationError, got %T", err)
+	}
+	if len(verr.Problems) != 3 {
+		t.Log(verr.Problems)
+		t.Fail()
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	content := `version: 1
+name: test
+constellation:
+  name: OneWeb
+  source: parquet
+  positions_file: ./constellation.parquet
+groundstations: ./groundstations.txt
+connections:
+  - source: ElAlamo
+    destination: Koto
+time:
+  start: 2022-09-11T12:00:00Z
+  step: 1s
+  duration: 2000s
+links:
+  max_fso_distance: 3000
+  access_point_range: 8
+netem:
+  rate: 100mbit
+  limit: 500
+output_dir: ./runs
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Steps() != 2000 {
+		t.Errorf("expected 2000 steps, got %d", s.Steps())
+	}
+	if !s.Time.Start.Equal(time.Date(2022, 9, 11, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("wrong start time %s", s.Time.Start)
+	}
+}
+
+func TestLoadRejectsUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1, "max_fso_distanse": 3000}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "max_fso_distanse") {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, ext := range []string{".json", ".yaml"} {
+		path := filepath.Join(t.TempDir(), "scenario"+ext)
+		s := Default()
+		if err := s.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Time.Step != s.Time.Step || loaded.Connections[0] != s.Connections[0] || !loaded.Time.Start.Equal(s.Time.Start) {
+			t.Errorf("%s round trip changed scenario: %+v", ext, loaded)
+		}
+	}
+}
+
+func TestValidateRouting(t *testing.T) {
+	s := Default()
+	s.Routing = RoutingNetwork
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Policy.Name = PolicyNoDrop
+	if err := s.Validate(); err == nil {
+		t.Error("network routing with the nodrop policy should not be valid")
+	}
+	s.Routing = "flooding"
+	if err := s.Validate(); err == nil {
+		t.Error("unknown routing mode should not be valid")
+	}
+}
+
+func TestValidateTopology(t *testing.T) {
+	s := Default()
+	s.Links.Topology = TopologyGreedy
+	if err := s.Validate(); err == nil {
+		t.Error("greedy topology without terminals should not be valid")
+	}
+	s.Links.Terminals = 4
+	s.Links.Hysteresis = 0.1
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Links.Topology = "mesh"
+	if err := s.Validate(); err == nil {
+		t.Error("unknown topology should not be valid")
+	}
+}
+
+func TestValidateStep(t *testing.T) {
+	s := Default()
+	s.Time.Step = MinStep
+	s.Policy.L2Interval = MinStep
+	s.Policy.L3Interval = 2 * MinStep
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Time.Step = Duration(5 * time.Millisecond)
+	if err := s.Validate(); err == nil {
+		t.Error("steps shorter than MinStep should not be valid")
+	}
+}
+
+func TestValidateNetemInterval(t *testing.T) {
+	s := Default()
+	s.Policy.L2Interval = s.Time.Step
+	s.Policy.NetemInterval = s.Time.Step / 5
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	if s.NetemUpdates() != 5 {
+		t.Errorf("expected 5 netem updates per step, got %d", s.NetemUpdates())
+	}
+	s.Policy.NetemInterval = s.Time.Step / 5 * 2
+	if err := s.Validate(); err == nil {
+		t.Error("a netem interval that does not divide the step should not be valid")
+	}
+	s.Policy.NetemInterval = s.Time.Step / 5
+	s.Policy.L2Interval = 2 * s.Time.Step
+	if err := s.Validate(); err == nil {
+		t.Error("a netem interval with l2_interval longer than the step should not be valid")
+	}
+	s.Policy.NetemInterval = 0
+	if s.NetemUpdates() != 1 {
+		t.Errorf("expected a single netem update per step, got %d", s.NetemUpdates())
+	}
+}
+
+func TestValidateWalker(t *testing.T) {
+	s := Default()
+	s.Constellation = Constellation{Name: "Starlink", Source: SourceWalker}
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	if c, err := s.Constellation.WalkerConstellation(); err != nil || c.Size() != 1584 {
+		t.Errorf("expected the Starlink preset, got %+v, %v", c, err)
+	}
+	s.Constellation = Constellation{Name: "shell", Source: SourceWalker, Walker: &walker.Constellation{
+		Pattern: walker.Delta, Planes: 4, SatellitesPerPlane: 10, Phasing: 1, Altitude: 500, Inclination: 60,
+	}}
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Constellation.Walker.Planes = 0
+	if err := s.Validate(); err == nil {
+		t.Error("walker constellation without planes should not be valid")
+	}
+}
+
+func TestValidatePropagator(t *testing.T) {
+	s := Default()
+	if s.Constellation.Model() != space.ModelSGP4 {
+		t.Errorf("tle constellations default to %q", s.Constellation.Model())
+	}
+	s.Constellation.Propagator = space.ModelJ2
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Constellation.Propagator = "n-body"
+	if err := s.Validate(); err == nil {
+		t.Error("unknown propagator should not be valid")
+	}
+	s.Constellation = Constellation{Name: "Kepler", Source: SourceWalker}
+	if s.Constellation.Model() != space.ModelKepler {
+		t.Errorf("walker constellations default to %q", s.Constellation.Model())
+	}
+	s.Constellation.Propagator = space.ModelSGP4
+	if err := s.Validate(); err == nil {
+		t.Error("walker constellations cannot be propagated with SGP4")
+	}
+}
+
+func TestValidateLinkLimits(t *testing.T) {
+	s := Default()
+	s.Links.MaxRelativeSpeed = 5
+	s.Links.MaxDoppler = 2
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Links.MaxDoppler = -1
+	if err := s.Validate(); err == nil {
+		t.Error("negative Doppler limit should not be valid")
+	}
+}
+
+func TestValidateGrazingHeight(t *testing.T) {
+	s := Default()
+	if s.Links.Grazing() != space.DefaultGrazingHeight {
+		t.Errorf("default grazing height %f", s.Links.Grazing())
+	}
+	zero := 0.0
+	s.Links.GrazingHeight = &zero
+	if err := s.Validate(); err != nil || s.Links.Grazing() != 0 {
+		t.Errorf("grazing height %f: %v", s.Links.Grazing(), err)
+	}
+	negative := -10.0
+	s.Links.GrazingHeight = &negative
+	if err := s.Validate(); err == nil {
+		t.Error("negative grazing height should not be valid")
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	s := Default()
+	s.Constellation.Window = 600
+	if err := s.Validate(); err != nil {
+		t.Errorf("window of 600 steps should be valid: %v", err)
+	}
+	s.Constellation.Window = -1
+	if err := s.Validate(); err == nil {
+		t.Error("negative window should not be valid")
+	}
+}
+
+func TestValidateHistory(t *testing.T) {
+	s := Default()
+	s.Constellation.History = true
+	if err := s.Validate(); err != nil {
+		t.Errorf("a TLE history should be valid: %v", err)
+	}
+	s.Constellation.Source = SourceWalker
+	if err := s.Validate(); err == nil {
+		t.Error("a walker constellation should not have a history")
+	}
+}
+
+func TestValidateSunExclusion(t *testing.T) {
+	s := Default()
+	s.Links.SunExclusion, s.Links.GroundSunExclusion = 5, 30
+	if err := s.Validate(); err != nil {
+		t.Errorf("sun exclusion angles should be valid: %v", err)
+	}
+	s.Links.SunExclusion = -1
+	if err := s.Validate(); err == nil {
+		t.Error("negative sun exclusion should not be valid")
+	}
+	s.Links.SunExclusion, s.Links.GroundSunExclusion = 0, 200
+	if err := s.Validate(); err == nil {
+		t.Error("ground sun exclusion above 180 degrees should not be valid")
+	}
+}
+
+func TestValidateCatalog(t *testing.T) {
+	s := Default()
+	s.Constellation = Constellation{Name: "OneWeb", Source: SourceCatalog, Catalog: &Catalog{Dir: "catalog", Group: "oneweb"}}
+	if err := s.Validate(); err != nil {
+		t.Error(err)
+	}
+	s.Constellation.Catalog = &Catalog{IDs: []int{44057}}
+	if err := s.Validate(); err != nil {
+		t.Errorf("ids from Space-Track should be valid: %v", err)
+	}
+	for _, catalog := range []*Catalog{nil, {Dir: "catalog"}, {Group: "oneweb"}, {IDs: []int{-1}}} {
+		s.Constellation.Catalog = catalog
+		if err := s.Validate(); err == nil {
+			t.Errorf("catalog %+v should not be valid", catalog)
+		}
+	}
+}
+
+func TestCatalogElementSets(t *testing.T) {
+	catalog, err := tle.OpenCatalog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := catalog.Import("../OneWeb", "oneweb"); err != nil {
+		t.Fatal(err)
+	}
+	group, err := catalog.Group("oneweb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Default()
+	s.GroundStations = "../groundstations.txt"
+	s.Time.Start = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	// an id of the group given again is looked up once
+	s.Constellation = Constellation{Name: "OneWeb", Source: SourceCatalog, Catalog: &Catalog{Dir: catalog.Dir, Group: "oneweb", IDs: []int{group[3]}}}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckInputs(); err != nil {
+		t.Fatal(err)
+	}
+	ids, sets, err := s.Constellation.Catalog.ElementSets(s.Time.Start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(group) || len(sets) != len(group) || ids[0] != group[0] {
+		t.Fatalf("expected the %d satellites of the group, got %d ids and %d sets", len(group), len(ids), len(sets))
+	}
+	if sets[0].Line1[2:7] != fmt.Sprintf("%05d", ids[0]) {
+		t.Errorf("set of %d is %s", ids[0], sets[0].Line1)
+	}
+	// element sets are only known after their epoch
+	s.Constellation.Catalog.IDs = nil
+	if _, _, err := s.Constellation.Catalog.ElementSets(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, tle.ErrNotInCatalog) {
+		t.Errorf("expected %v before the epochs, got %v", tle.ErrNotInCatalog, err)
+	}
+}
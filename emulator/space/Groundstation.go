@@ -5,6 +5,7 @@ import (
 	"errors"
 	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -34,6 +35,8 @@ type GroundStation struct {
 	IsAP     bool `parquet:"is_access_point"`
 	Isactive bool `parquet:"is_active"`
 	// altitude float64
+	MinElevation float64      // degrees, satellites lower than this are not visible
+	Horizon      *HorizonMask // optional, satellites below the mask are not visible
 }
 
 // Calculate distance from point A(Groundstation) to point B(satellite) on a sphere using Latitude and Longitude.
@@ -60,23 +63,15 @@ func CalculateDistance(latlong1 LatLong, latlong2 LatLong, max_distance float64)
 	return d, inCircle
 }
 
-// const minimumElevation = 25
-const minimumElevation = 25
-const footprint = 1500
-
-func SatelliteVisible(gs *GroundStation, satellite_ll LatLong) (visible bool, distance float64) {
-	// jday := gosat.JDay(time.Year(), int(time.Month()), time.Day(), time.Hour(), time.Minute(), time.Second())
-
-	// lookangle := gosat.ECIToLookAngles(satellitePosition.AsgosatVector(), gs.Latlong.asGosatLatLone(), 10, jday)
-	// gs.Latlong
-	d, incircle := CalculateDistance(gs.Latlong, satellite_ll, footprint)
-	// elevation = lookangle.El * (180 / math.Pi)
-	// https://github.com/joshuaferrara/go-satellite/issues/13
-	visible = incircle
-	distance = d
-	// visible = (elevation > minimumElevation) && elevation > 0
-	// log.Info().Float64("elevation", elevation).Bool("visible", visible).Float64("range", lookangle.Rg).Msg("look angle")
-	return visible, distance
+// SatelliteVisible reports whether the satellite at inertial position with latitude and longitude satellite_ll is above
+// the minimum elevation and the horizon mask of the ground station. distance is the slant range in km.
+func SatelliteVisible(gs *GroundStation, position Vector3, satellite_ll LatLong) (visible bool, distance float64) {
+	look := ComputeLookAngles(gs, position, satellite_ll)
+	visible = look.Elevation >= gs.MinElevation
+	if gs.Horizon != nil && look.Elevation < gs.Horizon.Elevation(look.Azimuth) {
+		visible = false
+	}
+	return visible, look.Range
 }
 
 func AccessPointVisible(gs1, gs2 *GroundStation, maxDistance float64) (inRange bool, distance float64) {
@@ -93,7 +88,8 @@ func GroundStationGen(title string, id int, lat float64, long float64, isAccessP
 			Latitude:  lat,
 			Longitude: long,
 		},
-		IsAP: isAccessPoint,
+		IsAP:         isAccessPoint,
+		MinElevation: DefaultMinimumElevation,
 	}
 }
 
@@ -104,7 +100,9 @@ func GroundStationGen(title string, id int, lat float64, long float64, isAccessP
 // }
 
 // returns slice of GroundStation objects
-// retrieves GS information from file (one GS for each line)
+// retrieves GS information from file (one GS for each line):
+// "title, latitude, longitude, is access point[, minimum elevation[, horizon mask file]]",
+// a relative horizon mask path is relative to the directory of the ground station file
 func LoadGroundStations(path string) ([]GroundStation, error) {
 	var groundStations []GroundStation
 	f, err := os.Open(path)
@@ -120,7 +118,7 @@ func LoadGroundStations(path string) ([]GroundStation, error) {
 		line := fileScanner.Text()
 		// split line into a slice of strings containing ["location name", "latitude", "longitude", "is access point bool"]
 		line_elements := strings.Split(line, ",")
-		if len(line_elements) != 4 {
+		if len(line_elements) < 4 || len(line_elements) > 6 {
 			return groundStations, errors.New("failed parsing line")
 		}
 
@@ -140,6 +138,22 @@ func LoadGroundStations(path string) ([]GroundStation, error) {
 			return groundStations, err
 		}
 		gs := GroundStationGen(title, GroundStationID, lattiude, longitude, isAP)
+		if len(line_elements) > 4 {
+			gs.MinElevation, err = strconv.ParseFloat(strings.TrimSpace(line_elements[4]), 64)
+			if err != nil {
+				return groundStations, err
+			}
+		}
+		if len(line_elements) > 5 {
+			maskPath := strings.TrimSpace(line_elements[5])
+			if !filepath.IsAbs(maskPath) {
+				maskPath = filepath.Join(filepath.Dir(path), maskPath)
+			}
+			gs.Horizon, err = LoadHorizonMask(maskPath)
+			if err != nil {
+				return groundStations, err
+			}
+		}
 		groundStations = append(groundStations, gs)
 	}
 	return groundStations, nil
@@ -153,9 +167,7 @@ func GroundStationECIPostions(gs GroundStation, startTime time.Time, timestep ti
 	var positions []Vector3
 	for i := 0; startTime.Before(endTime); i++ {
 		tempLatLong := LatLong{gs.Latlong.Latitude * math.Pi / 180, gs.Latlong.Longitude * math.Pi / 180}
-		jday := gosat.JDay(startTime.Year(), int(startTime.Month()), startTime.Day(), startTime.Hour(), startTime.Minute(), startTime.Second())
-		//gst := gosat.GSTimeFromDate(startTime.Year(), int(startTime.Month()), startTime.Day(), startTime.Hour(), startTime.Minute(), startTime.Second())
-		//position := gosat.LLAToECI(gs.Latlong.asGosatLatLone(), float64(altitude), gst)
+		jday := JulianDay(startTime)
 		position := gosat.LLAToECI(tempLatLong.asGosatLatLone(), float64(altitude), jday)
 		startTime = startTime.Add(timestep)
 		newPos := Vector3{
@@ -171,7 +183,7 @@ func GroundStationECIPostions(gs GroundStation, startTime time.Time, timestep ti
 // only for testing
 func GroundStationECIToLLAPostions(gs GroundStation, eciCoords []Vector3, startTime time.Time, timestep time.Duration, duration time.Duration) []Vector3 {
 	for i := 0; i <= 5; i++ {
-		jday := gosat.JDay(startTime.Year(), int(startTime.Month()), startTime.Day(), startTime.Hour(), startTime.Minute(), startTime.Second())
+		jday := JulianDay(startTime)
 		//gst := gosat.GSTimeFromDate(startTime.Year(), int(startTime.Month()), startTime.Day(), startTime.Hour(), startTime.Minute(), startTime.Second())
 		gmst := gosat.ThetaG_JD(jday)
 		_, _, ll := gosat.ECIToLLA(eciCoords[i].AsgosatVector(), gmst)
@@ -186,7 +198,7 @@ func GroundStationECIToLLAPostions(gs GroundStation, eciCoords []Vector3, startT
 // only for testing
 func GroundStationPositionTest(gs GroundStation, startTime time.Time) {
 
-	jday := gosat.JDay(startTime.Year(), int(startTime.Month()), startTime.Day(), startTime.Hour(), startTime.Minute(), startTime.Second())
+	jday := JulianDay(startTime)
 	tempLatLong := LatLong{gs.Latlong.Latitude * math.Pi / 180, gs.Latlong.Longitude * math.Pi / 180}
 
 	goecoord, _ := gosgp4.NewCoordGeodetic(gs.Latlong.Latitude, gs.Latlong.Longitude, 0.0, false)
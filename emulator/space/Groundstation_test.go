@@ -62,9 +62,15 @@ func TestSatelliteProjection(t *testing.T) {
 	startTime := time.Now().UTC()
 	log.Println(startTime)
 	aausat4_id := []int{41460}
-	satellites := tle.GetTLEfromNoradIDs(st, aausat4_id)
+	satellites, err := tle.GetTLEfromNoradIDs(st, aausat4_id, startTime)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	sat_data := GetSatData(satellites, []int{1}, startTime, 1*time.Second, 10*time.Second)
+	sat_data, err := GetSatData(satellites, []int{1}, startTime, 1*time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
 	satellite_data := sat_data[0]
 
 	ll := satellite_data.LatLong[0]
@@ -88,9 +94,15 @@ func TestSatelliteLookAngles(t *testing.T) {
 	startTime := time.Now().UTC()
 	log.Println(startTime)
 	aausat4_id := []int{41460}
-	satellites := tle.GetTLEfromNoradIDs(st, aausat4_id)
+	satellites, err := tle.GetTLEfromNoradIDs(st, aausat4_id, startTime)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	sat_data := GetSatData(satellites, []int{1}, startTime, 1*time.Second, 10*time.Second)
+	sat_data, err := GetSatData(satellites, []int{1}, startTime, 1*time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
 	satellite_data := sat_data[0]
 
 	ll := satellite_data.LatLong[0]
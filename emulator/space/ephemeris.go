@@ -0,0 +1,91 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOutsideHorizon is returned for times before the first or after the last step of the orbital data
+var ErrOutsideHorizon = errors.New("time is outside the orbital data")
+
+// Ephemeris interpolates the orbital data of a Provider to any time between its first and last step, step i being at Start+i*Step.
+// Between two steps a satellite follows the cubic Hermite spline through its positions and velocities at both steps,
+// or the straight line between the positions if its velocity is not known.
+type Ephemeris struct {
+	Orbits Provider
+	Start  time.Time
+	Step   time.Duration
+}
+
+// Locate returns the step at or before t and how far t is on towards the next step, 0 to 1
+func (e Ephemeris) Locate(t time.Time) (index int, fraction float64, err error) {
+	last := e.Orbits.Steps() - 1
+	offset := t.Sub(e.Start)
+	if e.Step <= 0 || last < 0 || offset < 0 || offset > time.Duration(last)*e.Step {
+		return 0, 0, fmt.Errorf("%w: %s is not within %s and the following %d steps of %s", ErrOutsideHorizon, t.Format(time.RFC3339Nano), e.Start.Format(time.RFC3339Nano), last, e.Step)
+	}
+	index = int(offset / e.Step)
+	fraction = float64(offset%e.Step) / float64(e.Step)
+	return index, fraction, nil
+}
+
+// StatesAt returns the state of every satellite at t
+func (e Ephemeris) StatesAt(t time.Time) ([]State, error) {
+	index, fraction, err := e.Locate(t)
+	if err != nil {
+		return nil, err
+	}
+	before := e.Orbits.At(index)
+	if fraction == 0 {
+		return before, nil
+	}
+	after := e.Orbits.At(index + 1)
+	states := make([]State, len(before))
+	for i := range before {
+		states[i] = interpolate(before[i], after[i], e.Step, fraction, t)
+	}
+	return states, nil
+}
+
+// StateAt returns the state of satellite sat, its index in the Provider, at t
+func (e Ephemeris) StateAt(sat int, t time.Time) (State, error) {
+	index, fraction, err := e.Locate(t)
+	if err != nil {
+		return State{}, err
+	}
+	before := e.Orbits.At(index)[sat]
+	if fraction == 0 {
+		return before, nil
+	}
+	return interpolate(before, e.Orbits.At(index + 1)[sat], e.Step, fraction, t), nil
+}
+
+// Distance returns the distance in km between two satellites at t
+func (e Ephemeris) Distance(sat1, sat2 int, t time.Time) (float64, error) {
+	states, err := e.StatesAt(t)
+	if err != nil {
+		return 0, err
+	}
+	return states[sat1].Position.Distance(states[sat2].Position), nil
+}
+
+// interpolate returns the state fraction of step on from s0 towards s1, at time t
+func interpolate(s0, s1 State, step time.Duration, fraction float64, t time.Time) State {
+	var s State
+	if s0.Velocity == (Vector3{}) && s1.Velocity == (Vector3{}) {
+		s.Position = Lerp(s0.Position, s1.Position, fraction)
+		d := s1.Position.Sub(s0.Position)
+		seconds := step.Seconds()
+		s.Velocity = newVector(d.X/seconds, d.Y/seconds, d.Z/seconds)
+	} else {
+		s.Position, s.Velocity = hermite(s0.Position, s0.Velocity, s1.Position, s1.Velocity, step.Seconds(), fraction)
+	}
+	s.LatLong = LLAFromPosition(s.Position, t)
+	return s
+}
+
+// Lerp returns the point fraction of the way from a to b
+func Lerp(a, b Vector3, fraction float64) Vector3 {
+	return newVector(a.X+(b.X-a.X)*fraction, a.Y+(b.Y-a.Y)*fraction, a.Z+(b.Z-a.Z)*fraction)
+}
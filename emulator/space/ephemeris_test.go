@@ -0,0 +1,56 @@
+package space
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEphemerisHermite(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	propagators := testPropagators()
+	ephemeris := Ephemeris{
+		Orbits: NewStreamProvider(make([]OrbitalData, len(propagators)), propagators, start, 10*time.Second, 10, 0),
+		Start:  start,
+		Step:   10 * time.Second,
+	}
+	for _, offset := range []time.Duration{0, 3700 * time.Millisecond, 45 * time.Second, 90 * time.Second} {
+		at := start.Add(offset)
+		states, err := ephemeris.StatesAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for sat, p := range propagators {
+			position, velocity := p.Propagate(at)
+			// over 10 s the spline stays within a millimetre of the orbit
+			if d := states[sat].Position.Distance(position); d > 1e-6 {
+				t.Errorf("satellite %d at %s is %g km off", sat, offset, d)
+			}
+			if d := states[sat].Velocity.Distance(velocity); d > 1e-6 {
+				t.Errorf("satellite %d at %s moves %g km/s off", sat, offset, d)
+			}
+		}
+		distance, err := ephemeris.Distance(0, 2, at)
+		if err != nil || distance != states[0].Position.Distance(states[2].Position) {
+			t.Errorf("distance %g: %v", distance, err)
+		}
+	}
+	for _, at := range []time.Time{start.Add(-time.Millisecond), start.Add(90*time.Second + time.Millisecond)} {
+		if _, err := ephemeris.StateAt(0, at); !errors.Is(err, ErrOutsideHorizon) {
+			t.Errorf("expected %v at %s, got %v", ErrOutsideHorizon, at, err)
+		}
+	}
+}
+
+func TestEphemerisWithoutVelocity(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	sat := OrbitalData{Position: []Vector3{{X: 7000}, {X: 7000, Y: 10}}}
+	ephemeris := Ephemeris{Orbits: NewSliceProvider([]OrbitalData{sat}, start), Start: start, Step: 2 * time.Second}
+	s, err := ephemeris.StateAt(0, start.Add(500*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Position != (Vector3{X: 7000, Y: 2.5}) || s.Velocity != (Vector3{Y: 5}) {
+		t.Errorf("expected the straight line between the steps, got %+v", s)
+	}
+}
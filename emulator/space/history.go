@@ -0,0 +1,80 @@
+package space
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// History propagates a satellite from several element sets, each time from the set with the epoch closest to it.
+// The position jumps by the difference between two sets where the closest one changes.
+type History struct {
+	epochs      []time.Time // sorted
+	propagators []Propagator
+}
+
+// NewHistory returns the propagator of model for the element sets of one satellite, sets has at least one element
+func NewHistory(model string, sets []gosat.Satellite) (Propagator, error) {
+	if len(sets) == 1 {
+		return NewPropagator(model, sets[0])
+	}
+	h := History{epochs: make([]time.Time, len(sets)), propagators: make([]Propagator, len(sets))}
+	order := make([]int, len(sets))
+	epochs := make([]time.Time, len(sets))
+	for i, sat := range sets {
+		elements, err := ElementsFromTLE(sat)
+		if err != nil {
+			return nil, err
+		}
+		order[i], epochs[i] = i, elements.Epoch
+	}
+	sort.SliceStable(order, func(a, b int) bool { return epochs[order[a]].Before(epochs[order[b]]) })
+	for i, set := range order {
+		propagator, err := NewPropagator(model, sets[set])
+		if err != nil {
+			return nil, err
+		}
+		h.epochs[i], h.propagators[i] = epochs[set], propagator
+	}
+	return h, nil
+}
+
+// Closest returns the index of the element set with the epoch closest to t, the later one of two equally close
+func (h History) Closest(t time.Time) int {
+	after := sort.Search(len(h.epochs), func(i int) bool { return !h.epochs[i].Before(t) })
+	switch {
+	case after == 0:
+		return 0
+	case after == len(h.epochs):
+		return after - 1
+	case t.Sub(h.epochs[after-1]) < h.epochs[after].Sub(t):
+		return after - 1
+	}
+	return after
+}
+
+func (h History) Propagate(t time.Time) (Vector3, Vector3) {
+	return h.propagators[h.Closest(t)].Propagate(t)
+}
+
+// NewPropagators returns the propagators of model for the satellites, sorted by id.
+// Element sets sharing an id are the history of one satellite and propagated by a History.
+func NewPropagators(model string, satelliteids []int, satellites []gosat.Satellite) (ids []int, propagators []Propagator, err error) {
+	sets := make(map[int][]gosat.Satellite)
+	for i, sat := range satellites {
+		if _, found := sets[satelliteids[i]]; !found {
+			ids = append(ids, satelliteids[i])
+		}
+		sets[satelliteids[i]] = append(sets[satelliteids[i]], sat)
+	}
+	sort.Ints(ids)
+	propagators = make([]Propagator, len(ids))
+	for i, id := range ids {
+		if propagators[i], err = NewHistory(model, sets[id]); err != nil {
+			return nil, nil, fmt.Errorf("satellite %d: %w", id, err)
+		}
+	}
+	return ids, propagators, nil
+}
@@ -0,0 +1,52 @@
+package space
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+func TestHistoryClosestEpoch(t *testing.T) {
+	early := gosat.TLEToSat(issLine1, issLine2, gosat.GravityWGS72)
+	late := gosat.TLEToSat(strings.Replace(issLine1, "23001.50000000", "23003.50000000", 1), issLine2, gosat.GravityWGS72)
+	// out of epoch order, with a second satellite in between
+	ids, propagators, err := NewPropagators(ModelSGP4, []int{25544, 7, 25544}, []gosat.Satellite{late, early, early})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 7 || ids[1] != 25544 {
+		t.Fatalf("expected satellites 7 and 25544, got %v", ids)
+	}
+	if _, ok := propagators[0].(SGP4); !ok {
+		t.Errorf("a single element set should be propagated by SGP4, got %T", propagators[0])
+	}
+	history, ok := propagators[1].(History)
+	if !ok {
+		t.Fatalf("expected a History, got %T", propagators[1])
+	}
+
+	epoch := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		at      time.Time
+		closest int
+	}{
+		{epoch.Add(-time.Hour), 0},
+		{epoch.Add(23 * time.Hour), 0},
+		{epoch.Add(24 * time.Hour), 1}, // equally close, the later set wins
+		{epoch.Add(25 * time.Hour), 1},
+		{epoch.Add(72 * time.Hour), 1},
+	}
+	for _, c := range cases {
+		if closest := history.Closest(c.at); closest != c.closest {
+			t.Errorf("at %s expected set %d, got %d", c.at, c.closest, closest)
+		}
+	}
+
+	at := epoch.Add(47 * time.Hour)
+	expected, _ := SGP4{Satellite: late}.Propagate(at)
+	if position, _ := history.Propagate(at); position != expected {
+		t.Errorf("expected the position of the later set %v, got %v", expected, position)
+	}
+}
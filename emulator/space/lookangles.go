@@ -0,0 +1,138 @@
+package space
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMinimumElevation is the elevation in degrees above which a satellite is visible from a ground station that sets none
+const DefaultMinimumElevation = 25
+
+// WGS84 ellipsoid
+const (
+	wgs84A  = 6378.137 // km
+	wgs84E2 = 6.69437999014e-3
+)
+
+// LookAngles point from a ground station to a satellite: azimuth clockwise from north and elevation above the horizon
+// in degrees, Range is the slant range in km
+type LookAngles struct {
+	Azimuth   float64
+	Elevation float64
+	Range     float64
+}
+
+// geodeticToECEF returns the earth fixed position of a point at latitude and longitude in degrees and altitude in km
+func geodeticToECEF(ll LatLong, altitude float64) Vector3 {
+	lat := ll.Latitude * math.Pi / 180
+	long := ll.Longitude * math.Pi / 180
+	n := wgs84A / math.Sqrt(1-wgs84E2*math.Sin(lat)*math.Sin(lat))
+	return Vector3{
+		X: (n + altitude) * math.Cos(lat) * math.Cos(long),
+		Y: (n + altitude) * math.Cos(lat) * math.Sin(long),
+		Z: (n*(1-wgs84E2) + altitude) * math.Sin(lat),
+	}
+}
+
+// satelliteECEF rotates the inertial position of a satellite into the earth fixed frame.
+// The sidereal angle follows from the longitude, which was computed from the same position.
+func satelliteECEF(position Vector3, ll LatLong) Vector3 {
+	theta := math.Atan2(position.Y, position.X) - ll.Longitude*math.Pi/180
+	return Vector3{
+		X: position.X*math.Cos(theta) + position.Y*math.Sin(theta),
+		Y: -position.X*math.Sin(theta) + position.Y*math.Cos(theta),
+		Z: position.Z,
+	}
+}
+
+// ComputeLookAngles returns the look angles from the ground station to a satellite at inertial position with latitude and longitude ll
+func ComputeLookAngles(gs *GroundStation, position Vector3, ll LatLong) LookAngles {
+	station := geodeticToECEF(gs.Latlong, 0)
+	d := satelliteECEF(position, ll).Sub(station)
+	lat := gs.Latlong.Latitude * math.Pi / 180
+	long := gs.Latlong.Longitude * math.Pi / 180
+	east := newVector(-math.Sin(long), math.Cos(long), 0)
+	north := newVector(-math.Sin(lat)*math.Cos(long), -math.Sin(lat)*math.Sin(long), math.Cos(lat))
+	up := newVector(math.Cos(lat)*math.Cos(long), math.Cos(lat)*math.Sin(long), math.Sin(lat))
+	slantRange := math.Sqrt(d.Dot(d))
+	azimuth := math.Mod(math.Atan2(d.Dot(east), d.Dot(north))*180/math.Pi+360, 360)
+	elevation := math.Asin(d.Dot(up)/slantRange) * 180 / math.Pi
+	return LookAngles{Azimuth: azimuth, Elevation: elevation, Range: slantRange}
+}
+
+// HorizonMask is the lowest elevation a ground station can see at each azimuth, e.g. because of terrain or buildings.
+// Between the points of the mask the elevation is interpolated linearly, wrapping around at 360 degrees.
+type HorizonMask struct {
+	Azimuths   []float64
+	Elevations []float64
+}
+
+// Elevation returns the elevation of the mask in degrees at azimuth
+func (m *HorizonMask) Elevation(azimuth float64) float64 {
+	n := len(m.Azimuths)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 {
+		return m.Elevations[0]
+	}
+	i := sort.SearchFloat64s(m.Azimuths, azimuth)
+	// the points around azimuth, the first and last point are neighbours across north
+	before, after := (i-1+n)%n, i%n
+	span := math.Mod(m.Azimuths[after]-m.Azimuths[before]+360, 360)
+	if span == 0 {
+		return m.Elevations[after]
+	}
+	fraction := math.Mod(azimuth-m.Azimuths[before]+360, 360) / span
+	return m.Elevations[before] + fraction*(m.Elevations[after]-m.Elevations[before])
+}
+
+// LoadHorizonMask reads a horizon mask file: one "azimuth elevation" pair in degrees per line, # starts a comment
+func LoadHorizonMask(path string) (*HorizonMask, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	type point struct{ azimuth, elevation float64 }
+	var points []point
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected azimuth and elevation, got %q", path, line, scanner.Text())
+		}
+		azimuth, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || azimuth < 0 || azimuth >= 360 {
+			return nil, fmt.Errorf("%s:%d: invalid azimuth %q", path, line, fields[0])
+		}
+		elevation, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || elevation < -90 || elevation > 90 {
+			return nil, fmt.Errorf("%s:%d: invalid elevation %q", path, line, fields[1])
+		}
+		points = append(points, point{azimuth, elevation})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%s: no points in horizon mask", path)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].azimuth < points[j].azimuth })
+	mask := &HorizonMask{}
+	for _, p := range points {
+		mask.Azimuths = append(mask.Azimuths, p.azimuth)
+		mask.Elevations = append(mask.Elevations, p.elevation)
+	}
+	return mask, nil
+}
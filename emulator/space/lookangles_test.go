@@ -0,0 +1,89 @@
+package space
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookAnglesOverhead(t *testing.T) {
+	gs := GroundStationGen("Null Island", 0, 0, 0, true)
+	// the same satellite straight above the station, seen at two sidereal times
+	for _, position := range []Vector3{{X: 6878.137}, {Y: 6878.137}} {
+		look := ComputeLookAngles(&gs, position, LatLong{0, 0})
+		if math.Abs(look.Elevation-90) > 1e-6 || math.Abs(look.Range-500) > 1e-6 {
+			t.Errorf("expected elevation 90 and range 500, got %+v", look)
+		}
+	}
+}
+
+func TestLookAnglesNorth(t *testing.T) {
+	gs := GroundStationGen("Null Island", 0, 0, 0, true)
+	lat := 5 * math.Pi / 180
+	position := Vector3{X: 6878 * math.Cos(lat), Z: 6878 * math.Sin(lat)}
+	look := ComputeLookAngles(&gs, position, LatLong{5, 0})
+	if math.Abs(look.Azimuth) > 1e-6 && math.Abs(look.Azimuth-360) > 1e-6 {
+		t.Errorf("expected azimuth 0, got %+v", look)
+	}
+	if look.Elevation < 30 || look.Elevation > 40 {
+		t.Errorf("expected an elevation around 38 degrees, got %+v", look)
+	}
+	visible, distance := SatelliteVisible(&gs, position, LatLong{5, 0})
+	if !visible || distance != look.Range {
+		t.Errorf("expected the satellite to be visible at the slant range, got %t %g", visible, distance)
+	}
+	gs.MinElevation = 40
+	if visible, _ := SatelliteVisible(&gs, position, LatLong{5, 0}); visible {
+		t.Error("satellite below the minimum elevation is visible")
+	}
+	gs.MinElevation = 0
+	gs.Horizon = &HorizonMask{Azimuths: []float64{0, 180}, Elevations: []float64{45, 0}}
+	if visible, _ := SatelliteVisible(&gs, position, LatLong{5, 0}); visible {
+		t.Error("satellite below the horizon mask is visible")
+	}
+}
+
+func TestHorizonMask(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mask.txt")
+	content := "# azimuth elevation\n90 10\n270 30 # hill\n\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mask, err := LoadHorizonMask(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for azimuth, expected := range map[float64]float64{90: 10, 180: 20, 270: 30, 0: 20, 315: 25} {
+		if elevation := mask.Elevation(azimuth); math.Abs(elevation-expected) > 1e-9 {
+			t.Errorf("azimuth %g: expected %g, got %g", azimuth, expected, elevation)
+		}
+	}
+	if err := os.WriteFile(path, []byte("90 10\n400 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHorizonMask(path); err == nil {
+		t.Error("expected an error for azimuth 400")
+	}
+}
+
+func TestGroundStationLoadElevation(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mask.txt"), []byte("0 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := "Madrid, 40.2, -4.0, true\nTokyo, 35.6, 139.7, true, 10, mask.txt\n"
+	if err := os.WriteFile(filepath.Join(dir, "groundstations.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	groundstations, err := LoadGroundStations(filepath.Join(dir, "groundstations.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groundstations[0].MinElevation != DefaultMinimumElevation || groundstations[0].Horizon != nil {
+		t.Errorf("expected the default minimum elevation and no mask, got %+v", groundstations[0])
+	}
+	if groundstations[1].MinElevation != 10 || groundstations[1].Horizon == nil {
+		t.Errorf("expected minimum elevation 10 and a mask, got %+v", groundstations[1])
+	}
+}
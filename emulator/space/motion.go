@@ -0,0 +1,54 @@
+package space
+
+import (
+	"math"
+	"time"
+)
+
+// CarrierFrequency of the optical inter-satellite links in Hz, a 1550 nm laser
+const CarrierFrequency = 193.4e12
+
+// VelocityAt returns the velocity of the satellite in km/s at step index, zero if it is not known
+func (sat OrbitalData) VelocityAt(index int) Vector3 {
+	if index < len(sat.Velocity) {
+		return sat.Velocity[index]
+	}
+	return Vector3{}
+}
+
+// RelativeMotion returns how satellite b moves as seen from satellite a: speed is the magnitude of the relative
+// velocity in km/s and rangeRate its component along the line of sight, positive while the satellites move apart
+func RelativeMotion(positionA, velocityA, positionB, velocityB Vector3) (speed, rangeRate float64) {
+	velocity := velocityB.Sub(velocityA)
+	line := positionB.Sub(positionA)
+	speed = velocity.Speed()
+	if distance := line.magnitude(); distance > 0 {
+		rangeRate = velocity.Dot(line) / distance
+	}
+	return speed, rangeRate
+}
+
+// Doppler returns the Doppler shift in Hz of a carrier of frequency Hz between two satellites whose range changes at rangeRate km/s
+func Doppler(frequency, rangeRate float64) float64 {
+	return frequency * math.Abs(rangeRate) / C
+}
+
+// DeriveVelocity fills the velocity of every step from the positions step apart, for sources that only have positions.
+// Inner steps use the central difference, the first and last step the one sided difference.
+func (sat *OrbitalData) DeriveVelocity(step time.Duration) {
+	n := len(sat.Position)
+	sat.Velocity = make([]Vector3, n)
+	if n < 2 {
+		return
+	}
+	difference := func(from, to int) Vector3 {
+		d := sat.Position[to].Sub(sat.Position[from])
+		seconds := float64(to-from) * step.Seconds()
+		return newVector(d.X/seconds, d.Y/seconds, d.Z/seconds)
+	}
+	sat.Velocity[0] = difference(0, 1)
+	for i := 1; i < n-1; i++ {
+		sat.Velocity[i] = difference(i-1, i+1)
+	}
+	sat.Velocity[n-1] = difference(n-2, n-1)
+}
@@ -0,0 +1,39 @@
+package space
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpeed(t *testing.T) {
+	if speed := newVector(1, -2, 2).Speed(); speed != 3 {
+		t.Errorf("speed of (1, -2, 2) is %f, expected 3", speed)
+	}
+}
+
+func TestRelativeMotion(t *testing.T) {
+	// b is 100 km ahead of a on the x axis, moving away at 1 km/s and sideways at 2 km/s
+	speed, rangeRate := RelativeMotion(newVector(0, 0, 0), newVector(7, 0, 0), newVector(100, 0, 0), newVector(8, 2, 0))
+	if math.Abs(speed-math.Sqrt(5)) > 1e-12 {
+		t.Errorf("relative speed %f", speed)
+	}
+	if rangeRate != 1 {
+		t.Errorf("range rate %f, expected 1", rangeRate)
+	}
+	// 1 km/s changes a 193.4 THz carrier by about 645 MHz
+	if shift := Doppler(CarrierFrequency, -rangeRate); math.Abs(shift-645.1e6) > 1e6 {
+		t.Errorf("Doppler shift %f Hz", shift)
+	}
+}
+
+func TestDeriveVelocity(t *testing.T) {
+	sat := OrbitalData{Position: []Vector3{{X: 0}, {X: 10}, {X: 30}}}
+	sat.DeriveVelocity(2 * time.Second)
+	expected := []float64{5, 7.5, 10}
+	for i, v := range sat.Velocity {
+		if v.X != expected[i] || v.Y != 0 || v.Z != 0 {
+			t.Errorf("velocity at step %d is %v, expected %f", i, v, expected[i])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package space
+
+import (
+	"math"
+	"sort"
+)
+
+// PlaneTolerance is the largest angle in degrees between the orbit normals of two satellites in the same orbital plane
+const PlaneTolerance = 2.0
+
+// Plane is a group of satellites sharing an orbit plane.
+// Satellites holds indexes into satdata ordered by argument of latitude, the position in it is the slot of a satellite.
+type Plane struct {
+	RAAN       float64 // right ascension of the ascending node in degrees, 0 to 360
+	Normal     Vector3 // unit vector along the angular momentum
+	Satellites []int
+}
+
+func (a Vector3) Dot(b Vector3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func (a Vector3) Cross(b Vector3) Vector3 {
+	return newVector(a.Y*b.Z-a.Z*b.Y, a.Z*b.X-a.X*b.Z, a.X*b.Y-a.Y*b.X)
+}
+
+func (a Vector3) unit() Vector3 {
+	length := math.Sqrt(a.Dot(a))
+	if length == 0 {
+		return a
+	}
+	return newVector(a.X/length, a.Y/length, a.Z/length)
+}
+
+// motion returns the velocity of the satellite at step index, or its displacement to a neighbouring step if the velocity is not known
+func (sat OrbitalData) motion(index int) Vector3 {
+	if index < len(sat.Velocity) && sat.Velocity[index] != (Vector3{}) {
+		return sat.Velocity[index]
+	}
+	if index+1 < len(sat.Position) {
+		return sat.Position[index+1].Sub(sat.Position[index])
+	}
+	if index > 0 {
+		return sat.Position[index].Sub(sat.Position[index-1])
+	}
+	return Vector3{}
+}
+
+// OrbitNormal returns the unit vector along the angular momentum of the satellite at step index
+func (sat OrbitalData) OrbitNormal(index int) Vector3 {
+	return sat.Position[index].Cross(sat.motion(index)).unit()
+}
+
+// ascendingNode returns the unit vector towards the ascending node of an orbit with the given normal
+func ascendingNode(normal Vector3) Vector3 {
+	node := newVector(-normal.Y, normal.X, 0)
+	if node == (Vector3{}) {
+		return newVector(1, 0, 0) // equatorial orbit
+	}
+	return node.unit()
+}
+
+// argumentOfLatitude is the angle in radians from the ascending node to the position in the direction of motion, 0 to 2 pi
+func argumentOfLatitude(position, normal Vector3) float64 {
+	node := ascendingNode(normal)
+	u := math.Atan2(normal.Cross(node).Dot(position), node.Dot(position))
+	if u < 0 {
+		u += 2 * math.Pi
+	}
+	return u
+}
+
+// OrbitalPlanes groups the satellites into orbital planes from their motion at step index.
+// Planes are ordered by RAAN, the satellites of a plane by argument of latitude.
+func OrbitalPlanes(satdata []OrbitalData, index int) []Plane {
+	normals := make([]Vector3, len(satdata))
+	positions := make([]Vector3, len(satdata))
+	for i, sat := range satdata {
+		normals[i] = sat.OrbitNormal(index)
+		positions[i] = sat.Position[index]
+	}
+	return groupPlanes(normals, positions)
+}
+
+// PlanesAt groups the satellites into orbital planes from their states at one step, the velocities must be known
+func PlanesAt(states []State) []Plane {
+	normals := make([]Vector3, len(states))
+	positions := make([]Vector3, len(states))
+	for i, s := range states {
+		normals[i] = s.Position.Cross(s.Velocity).unit()
+		positions[i] = s.Position
+	}
+	return groupPlanes(normals, positions)
+}
+
+func groupPlanes(normals, positions []Vector3) (planes []Plane) {
+	tolerance := math.Cos(PlaneTolerance * math.Pi / 180)
+	for i, normal := range normals {
+		found := false
+		for p := range planes {
+			if planes[p].Normal.Dot(normal) >= tolerance {
+				planes[p].Satellites = append(planes[p].Satellites, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			node := ascendingNode(normal)
+			raan := math.Mod(math.Atan2(node.Y, node.X)*180/math.Pi+360, 360)
+			planes = append(planes, Plane{RAAN: raan, Normal: normal, Satellites: []int{i}})
+		}
+	}
+	for _, plane := range planes {
+		u := make(map[int]float64, len(plane.Satellites))
+		for _, i := range plane.Satellites {
+			u[i] = argumentOfLatitude(positions[i], plane.Normal)
+		}
+		sort.Slice(plane.Satellites, func(a, b int) bool {
+			return u[plane.Satellites[a]] < u[plane.Satellites[b]]
+		})
+	}
+	sort.Slice(planes, func(a, b int) bool {
+		return planes[a].RAAN < planes[b].RAAN
+	})
+	return planes
+}
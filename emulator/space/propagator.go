@@ -0,0 +1,188 @@
+package space
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// Propagation models
+const (
+	ModelSGP4   = "sgp4"   // SGP4 of go-satellite, including drag and the deep space terms
+	ModelKepler = "kepler" // two-body orbits, the planes never move
+	ModelJ2     = "j2"     // two-body orbits with the secular drift of the node, perigee and mean anomaly caused by J2
+)
+
+var Models = []string{ModelSGP4, ModelKepler, ModelJ2}
+
+const (
+	mu          = 398600.4418 // km^3/s^2, gravitational parameter of the earth
+	earthRadius = 6378.137    // km, equatorial radius used with J2
+	j2          = 1.08262668e-3
+)
+
+// Propagator computes the position in km and velocity in km/s of one satellite in the inertial frame at a point in time
+type Propagator interface {
+	Propagate(t time.Time) (position, velocity Vector3)
+}
+
+// SGP4 propagates a satellite from its two line elements
+type SGP4 struct {
+	Satellite gosat.Satellite
+}
+
+func (p SGP4) Propagate(t time.Time) (Vector3, Vector3) {
+	return propagate(p.Satellite, t)
+}
+
+// Elements are the classical orbital elements of a satellite at Epoch, angles in radians
+type Elements struct {
+	Epoch             time.Time
+	SemiMajorAxis     float64 // km
+	Eccentricity      float64
+	Inclination       float64
+	RAAN              float64
+	ArgumentOfPerigee float64
+	MeanAnomaly       float64
+}
+
+// MeanMotion is the mean motion of the two-body orbit in rad/s
+func (e Elements) MeanMotion() float64 {
+	return math.Sqrt(mu / (e.SemiMajorAxis * e.SemiMajorAxis * e.SemiMajorAxis))
+}
+
+// Kepler propagates the two-body orbit of the elements
+type Kepler struct {
+	Elements
+}
+
+func (p Kepler) Propagate(t time.Time) (Vector3, Vector3) {
+	dt := t.Sub(p.Epoch).Seconds()
+	return p.state(p.RAAN, p.ArgumentOfPerigee, p.MeanAnomaly+p.MeanMotion()*dt)
+}
+
+// J2 propagates the two-body orbit of the elements with the secular rates caused by the oblateness of the earth,
+// which is what makes the planes of LEO constellations drift by several degrees per day
+type J2 struct {
+	Elements
+}
+
+// Rates returns the drift of the RAAN, the argument of perigee and the mean anomaly in rad/s
+func (p J2) Rates() (raan, perigee, anomaly float64) {
+	n := p.MeanMotion()
+	e2 := p.Eccentricity * p.Eccentricity
+	semiLatusRectum := p.SemiMajorAxis * (1 - e2)
+	factor := 1.5 * j2 * (earthRadius / semiLatusRectum) * (earthRadius / semiLatusRectum) * n
+	sin2 := math.Sin(p.Inclination) * math.Sin(p.Inclination)
+	raan = -factor * math.Cos(p.Inclination)
+	perigee = factor * (2 - 2.5*sin2)
+	anomaly = n + factor*math.Sqrt(1-e2)*(1-1.5*sin2)
+	return raan, perigee, anomaly
+}
+
+func (p J2) Propagate(t time.Time) (Vector3, Vector3) {
+	dt := t.Sub(p.Epoch).Seconds()
+	raan, perigee, anomaly := p.Rates()
+	return p.state(p.RAAN+raan*dt, p.ArgumentOfPerigee+perigee*dt, p.MeanAnomaly+anomaly*dt)
+}
+
+// state returns position and velocity on the orbit of e with the given node, perigee and mean anomaly
+func (e Elements) state(raan, perigee, meanAnomaly float64) (position, velocity Vector3) {
+	ecc := e.Eccentricity
+	// solve Kepler's equation E - e sin E = M
+	meanAnomaly = math.Mod(meanAnomaly, 2*math.Pi)
+	eccentric := meanAnomaly
+	for i := 0; i < 20; i++ {
+		delta := (eccentric - ecc*math.Sin(eccentric) - meanAnomaly) / (1 - ecc*math.Cos(eccentric))
+		eccentric -= delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+	trueAnomaly := 2 * math.Atan2(math.Sqrt(1+ecc)*math.Sin(eccentric/2), math.Sqrt(1-ecc)*math.Cos(eccentric/2))
+	semiLatusRectum := e.SemiMajorAxis * (1 - ecc*ecc)
+	radius := semiLatusRectum / (1 + ecc*math.Cos(trueAnomaly))
+	speed := math.Sqrt(mu / semiLatusRectum)
+
+	// perifocal frame: P towards perigee, Q 90 degrees ahead in the plane
+	cosO, sinO := math.Cos(raan), math.Sin(raan)
+	cosW, sinW := math.Cos(perigee), math.Sin(perigee)
+	cosI, sinI := math.Cos(e.Inclination), math.Sin(e.Inclination)
+	p := newVector(cosO*cosW-sinO*sinW*cosI, sinO*cosW+cosO*sinW*cosI, sinW*sinI)
+	q := newVector(-cosO*sinW-sinO*cosW*cosI, -sinO*sinW+cosO*cosW*cosI, cosW*sinI)
+
+	x, y := radius*math.Cos(trueAnomaly), radius*math.Sin(trueAnomaly)
+	vx, vy := -speed*math.Sin(trueAnomaly), speed*(ecc+math.Cos(trueAnomaly))
+	position = newVector(x*p.X+y*q.X, x*p.Y+y*q.Y, x*p.Z+y*q.Z)
+	velocity = newVector(vx*p.X+vy*q.X, vx*p.Y+vy*q.Y, vx*p.Z+vy*q.Z)
+	return position, velocity
+}
+
+// ElementsFromTLE reads the mean elements and epoch of the two line elements of sat
+func ElementsFromTLE(sat gosat.Satellite) (Elements, error) {
+	line1, line2 := sat.Line1, sat.Line2
+	if len(line1) < 32 || len(line2) < 63 {
+		return Elements{}, fmt.Errorf("two line elements are too short: %q %q", line1, line2)
+	}
+	field := func(line string, from, to int) (float64, error) {
+		return strconv.ParseFloat(strings.TrimSpace(line[from:to]), 64)
+	}
+	var values [7]float64
+	var err error
+	for i, f := range []struct {
+		line     string
+		from, to int
+	}{{line1, 18, 20}, {line1, 20, 32}, {line2, 8, 16}, {line2, 17, 25}, {line2, 34, 42}, {line2, 43, 51}, {line2, 52, 63}} {
+		if values[i], err = field(f.line, f.from, f.to); err != nil {
+			return Elements{}, fmt.Errorf("invalid two line elements: %w", err)
+		}
+	}
+	eccentricity, err := strconv.ParseFloat("."+strings.TrimSpace(line2[26:33]), 64)
+	if err != nil {
+		return Elements{}, fmt.Errorf("invalid eccentricity: %w", err)
+	}
+
+	year := int(values[0]) + 2000
+	if values[0] >= 57 {
+		year -= 100
+	}
+	epoch := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration((values[1] - 1) * float64(24*time.Hour)))
+	motion := values[6] * 2 * math.Pi / 86400 // rev/day to rad/s
+	degrees := math.Pi / 180
+	return Elements{
+		Epoch:             epoch,
+		SemiMajorAxis:     math.Cbrt(mu / (motion * motion)),
+		Eccentricity:      eccentricity,
+		Inclination:       values[2] * degrees,
+		RAAN:              values[3] * degrees,
+		ArgumentOfPerigee: values[4] * degrees,
+		MeanAnomaly:       values[5] * degrees,
+	}, nil
+}
+
+// NewPropagator returns the propagator of model for a satellite loaded from two line elements
+func NewPropagator(model string, sat gosat.Satellite) (Propagator, error) {
+	if model == ModelSGP4 || model == "" {
+		return SGP4{Satellite: sat}, nil
+	}
+	elements, err := ElementsFromTLE(sat)
+	if err != nil {
+		return nil, err
+	}
+	return ElementsPropagator(model, elements)
+}
+
+// ElementsPropagator returns the propagator of model for the orbit with the elements, SGP4 needs two line elements
+func ElementsPropagator(model string, elements Elements) (Propagator, error) {
+	switch model {
+	case ModelKepler, "":
+		return Kepler{elements}, nil
+	case ModelJ2:
+		return J2{elements}, nil
+	}
+	return nil, fmt.Errorf("model %q cannot propagate orbital elements, use %q or %q", model, ModelKepler, ModelJ2)
+}
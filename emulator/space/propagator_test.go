@@ -0,0 +1,103 @@
+package space
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+func TestElementsFromTLE(t *testing.T) {
+	elements, err := ElementsFromTLE(gosat.TLEToSat(issLine1, issLine2, gosat.GravityWGS72))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC); !elements.Epoch.Equal(epoch) {
+		t.Errorf("epoch %s, expected %s", elements.Epoch, epoch)
+	}
+	if math.Abs(elements.Inclination*180/math.Pi-51.6416) > 1e-9 {
+		t.Errorf("inclination %f", elements.Inclination*180/math.Pi)
+	}
+	if math.Abs(elements.Eccentricity-0.000522) > 1e-12 {
+		t.Errorf("eccentricity %f", elements.Eccentricity)
+	}
+	if elements.SemiMajorAxis < 6790 || elements.SemiMajorAxis > 6800 {
+		t.Errorf("semi-major axis %f km", elements.SemiMajorAxis)
+	}
+	if _, err := ElementsFromTLE(gosat.Satellite{Line1: "1 25544U", Line2: "2 25544"}); err == nil {
+		t.Error("expected an error for truncated two line elements")
+	}
+}
+
+func TestKeplerOrbit(t *testing.T) {
+	epoch := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := Elements{Epoch: epoch, SemiMajorAxis: 7500, Eccentricity: 0.1, Inclination: 1, RAAN: 2, ArgumentOfPerigee: 0.5, MeanAnomaly: 0.3}
+	p := Kepler{elements}
+	start, _ := p.Propagate(epoch)
+	period := time.Duration(2 * math.Pi / elements.MeanMotion() * float64(time.Second))
+	for at := epoch; at.Before(epoch.Add(period)); at = at.Add(time.Minute) {
+		position, velocity := p.Propagate(at)
+		radius := math.Sqrt(position.Dot(position))
+		speed2 := velocity.Dot(velocity)
+		// vis-viva: the energy of a two-body orbit is constant
+		if expected := mu * (2/radius - 1/elements.SemiMajorAxis); math.Abs(speed2-expected) > 1e-6 {
+			t.Fatalf("speed squared %f at %s, expected %f", speed2, at, expected)
+		}
+	}
+	if end, _ := p.Propagate(epoch.Add(period)); end.Distance(start) > 1e-3 {
+		t.Errorf("satellite is %f km away from its start after one period", end.Distance(start))
+	}
+}
+
+// raanOf returns the RAAN in degrees of the orbit through position with velocity
+func raanOf(position, velocity Vector3) float64 {
+	node := ascendingNode(position.Cross(velocity).unit())
+	return math.Atan2(node.Y, node.X) * 180 / math.Pi
+}
+
+func TestJ2FollowsSGP4(t *testing.T) {
+	sat := gosat.TLEToSat(issLine1, issLine2, gosat.GravityWGS72)
+	elements, err := ElementsFromTLE(sat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raanRate, _, _ := J2{elements}.Rates()
+	if perDay := raanRate * 86400 * 180 / math.Pi; perDay > -4.5 || perDay < -5.5 {
+		t.Errorf("the ISS node drifts %f degrees per day, expected about -5", perDay)
+	}
+
+	at := elements.Epoch.Add(72 * time.Hour)
+	drift := func(p Propagator) float64 {
+		start := raanOf(p.Propagate(elements.Epoch))
+		return math.Mod(raanOf(p.Propagate(at))-start+540, 360) - 180
+	}
+	sgp4, kepler, withJ2 := drift(SGP4{sat}), drift(Kepler{elements}), drift(J2{elements})
+	t.Logf("RAAN drift over 3 days: sgp4 %f kepler %f j2 %f degrees", sgp4, kepler, withJ2)
+	if math.Abs(withJ2-sgp4) > 0.1 {
+		t.Errorf("J2 drifts %f degrees, SGP4 %f", withJ2, sgp4)
+	}
+	if math.Abs(kepler) > 0.01 {
+		t.Errorf("two-body orbit drifts %f degrees", kepler)
+	}
+}
+
+func TestNewPropagator(t *testing.T) {
+	sat := gosat.TLEToSat(issLine1, issLine2, gosat.GravityWGS72)
+	for _, model := range Models {
+		p, err := NewPropagator(model, sat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the mean elements of a TLE are within some km of the SGP4 state at epoch
+		elements, _ := ElementsFromTLE(sat)
+		position, _ := p.Propagate(elements.Epoch)
+		reference, _ := SGP4{sat}.Propagate(elements.Epoch)
+		if distance := position.Distance(reference); distance > 50 {
+			t.Errorf("%s is %f km away from SGP4 at epoch", model, distance)
+		}
+	}
+	if _, err := NewPropagator("n-body", sat); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
@@ -0,0 +1,203 @@
+package space
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWindow is the number of steps a StreamProvider propagates ahead at a time
+const DefaultWindow = 100
+
+// State is where a satellite is and how it moves at one step
+type State struct {
+	Position Vector3 // km, inertial frame
+	Velocity Vector3 // km/s, zero if not known
+	LatLong  LatLong
+}
+
+// Provider serves the orbital data of a constellation one step at a time, so a run never needs every step in memory.
+// Satellites are sorted by id and their index is their graph id; At returns their states at step index in the same order.
+type Provider interface {
+	// Satellites returns the satellites without their positions
+	Satellites() []OrbitalData
+	// Start is the time of step 0
+	Start() time.Time
+	// Steps is the number of steps the provider can serve
+	Steps() int
+	// At returns the state of every satellite at step index, which must be below Steps
+	At(index int) []State
+}
+
+// StateAt returns the state of the satellite at step index
+func (sat OrbitalData) StateAt(index int) State {
+	s := State{Position: sat.Position[index], Velocity: sat.VelocityAt(index)}
+	if index < len(sat.LatLong) {
+		s.LatLong = sat.LatLong[index]
+	}
+	return s
+}
+
+// States returns the state of every satellite at step index
+func States(satdata []OrbitalData, index int) []State {
+	states := make([]State, len(satdata))
+	for i, sat := range satdata {
+		states[i] = sat.StateAt(index)
+	}
+	return states
+}
+
+// withoutSteps copies the satellites without their positions
+func withoutSteps(satdata []OrbitalData) []OrbitalData {
+	satellites := make([]OrbitalData, len(satdata))
+	for i, sat := range satdata {
+		satellites[i] = sat
+		satellites[i].Position, satellites[i].Velocity, satellites[i].LatLong = nil, nil, nil
+	}
+	return satellites
+}
+
+// SliceProvider serves orbital data that is already in memory, satdata must be sorted by satellite id
+type SliceProvider struct {
+	satdata []OrbitalData
+	start   time.Time
+}
+
+// NewSliceProvider serves the positions of satdata, position i at start plus i steps
+func NewSliceProvider(satdata []OrbitalData, start time.Time) *SliceProvider {
+	return &SliceProvider{satdata: satdata, start: start}
+}
+
+func (p *SliceProvider) Start() time.Time {
+	return p.start
+}
+
+func (p *SliceProvider) Satellites() []OrbitalData {
+	return withoutSteps(p.satdata)
+}
+
+func (p *SliceProvider) Steps() int {
+	if len(p.satdata) == 0 {
+		return 0
+	}
+	return len(p.satdata[0].Position)
+}
+
+func (p *SliceProvider) At(index int) []State {
+	return States(p.satdata, index)
+}
+
+// StreamProvider propagates the satellites on demand, Window steps at a time.
+// It holds the two windows used last, so a policy looking ahead does not make the current window be propagated again.
+type StreamProvider struct {
+	satellites  []OrbitalData
+	propagators []Propagator
+	start       time.Time
+	step        time.Duration
+	steps       int
+	window      int
+
+	mu      sync.Mutex
+	windows []*window // most recently used first
+}
+
+type window struct {
+	first  int
+	states [][]State // states[i][sat] is step first+i
+}
+
+// NewStreamProvider serves steps steps of the satellites, step i at start+i*step, propagating window steps at a time.
+// propagators[i] moves satellites[i]; both are sorted by satellite id together. window 0 means DefaultWindow.
+func NewStreamProvider(satellites []OrbitalData, propagators []Propagator, start time.Time, step time.Duration, steps int, window int) *StreamProvider {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	p := &StreamProvider{
+		satellites:  withoutSteps(satellites),
+		propagators: append([]Propagator{}, propagators...),
+		start:       start,
+		step:        step,
+		steps:       steps,
+		window:      window,
+	}
+	sort.Sort(streamByID{p})
+	return p
+}
+
+type streamByID struct{ p *StreamProvider }
+
+func (s streamByID) Len() int { return len(s.p.satellites) }
+func (s streamByID) Less(i, j int) bool {
+	return s.p.satellites[i].SatelliteId < s.p.satellites[j].SatelliteId
+}
+func (s streamByID) Swap(i, j int) {
+	s.p.satellites[i], s.p.satellites[j] = s.p.satellites[j], s.p.satellites[i]
+	s.p.propagators[i], s.p.propagators[j] = s.p.propagators[j], s.p.propagators[i]
+}
+
+func (p *StreamProvider) Satellites() []OrbitalData {
+	return withoutSteps(p.satellites)
+}
+
+func (p *StreamProvider) Start() time.Time {
+	return p.start
+}
+
+func (p *StreamProvider) Steps() int {
+	return p.steps
+}
+
+func (p *StreamProvider) At(index int) []State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, w := range p.windows {
+		if index >= w.first && index < w.first+len(w.states) {
+			copy(p.windows[1:i+1], p.windows[:i])
+			p.windows[0] = w
+			return w.states[index-w.first]
+		}
+	}
+	w := p.propagate(index)
+	p.windows = append([]*window{w}, p.windows...)
+	if len(p.windows) > 2 {
+		p.windows = p.windows[:2]
+	}
+	return w.states[index-w.first]
+}
+
+// propagate computes the window starting at step first, the satellites are spread over one worker per CPU
+func (p *StreamProvider) propagate(first int) *window {
+	count := p.window
+	if first+count > p.steps {
+		count = p.steps - first
+	}
+	if count < 1 {
+		count = 1
+	}
+	w := &window{first: first, states: make([][]State, count)}
+	for i := range w.states {
+		w.states[i] = make([]State, len(p.propagators))
+	}
+	jobs := make(chan int, len(p.propagators))
+	for sat := range p.propagators {
+		jobs <- sat
+	}
+	close(jobs)
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < runtime.NumCPU(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sat := range jobs {
+				for i := range w.states {
+					at := p.start.Add(time.Duration(first+i) * p.step)
+					position, velocity := p.propagators[sat].Propagate(at)
+					w.states[i][sat] = State{Position: position, Velocity: velocity, LatLong: LLAFromPosition(position, at)}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return w
+}
@@ -0,0 +1,67 @@
+package space
+
+import (
+	"testing"
+	"time"
+)
+
+func testPropagators() []Propagator {
+	var propagators []Propagator
+	for i := 0; i < 3; i++ {
+		propagators = append(propagators, Kepler{Elements{
+			Epoch:         time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			SemiMajorAxis: 7000 + 100*float64(i),
+			Inclination:   0.9,
+			RAAN:          float64(i),
+		}})
+	}
+	return propagators
+}
+
+func TestStreamProviderMatchesPropagateSatellites(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	propagators := testPropagators()
+	ids := []int{12, 10, 11}
+	satdata := PropagateSatellites(propagators, ids, start, 10*time.Second, 50*time.Second)
+
+	satellites := []OrbitalData{{SatelliteId: 12}, {SatelliteId: 10}, {SatelliteId: 11}}
+	stream := NewStreamProvider(satellites, propagators, start.Add(10*time.Second), 10*time.Second, 5, 2)
+	slice := NewSliceProvider(satdata, start.Add(10*time.Second))
+	if stream.Steps() != 5 || slice.Steps() != 5 {
+		t.Fatalf("expected 5 steps, got %d and %d", stream.Steps(), slice.Steps())
+	}
+	for i, sat := range stream.Satellites() {
+		if sat.SatelliteId != 10+i || sat.Position != nil {
+			t.Errorf("satellite %d: id %d with %d positions", i, sat.SatelliteId, len(sat.Position))
+		}
+	}
+	// backwards as well, so windows are dropped and propagated again
+	for _, index := range []int{0, 1, 2, 4, 3, 0} {
+		want := slice.At(index)
+		for sat, got := range stream.At(index) {
+			if got.Position.Distance(want[sat].Position) > 1e-6 || got.Velocity != want[sat].Velocity || got.LatLong != want[sat].LatLong {
+				t.Errorf("step %d satellite %d: %+v, expected %+v", index, sat, got, want[sat])
+			}
+		}
+	}
+	if len(stream.windows) > 2 {
+		t.Errorf("expected at most 2 windows, holding %d", len(stream.windows))
+	}
+}
+
+func TestStreamProviderKeepsLookaheadWindow(t *testing.T) {
+	stream := NewStreamProvider(make([]OrbitalData, 3), testPropagators(), time.Now(), time.Second, 1000, 10)
+	// the current step and a step further ahead than the window, as a policy looking ahead reads them
+	for index := 0; index < 100; index++ {
+		stream.At(index)
+		stream.At(index + 50)
+	}
+	for _, w := range stream.windows {
+		if len(w.states) != 10 {
+			t.Errorf("window at %d holds %d steps", w.first, len(w.states))
+		}
+	}
+	if first := stream.windows[0].first; first != 140 {
+		t.Errorf("expected the last window to start at 140, got %d", first)
+	}
+}
@@ -0,0 +1,133 @@
+package space
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// A satellite belongs to a shell if its inclination is within ShellInclinationTolerance degrees of the mean of the shell
+// and its mean altitude within ShellAltitudeTolerance km of a satellite of the shell. Planes of a shell are often
+// spread over some tens of km in altitude, so that they do not collide where they cross.
+const (
+	ShellAltitudeTolerance    = 5.0
+	ShellInclinationTolerance = 0.5
+)
+
+// Shell is a group of satellites at about the same altitude and inclination, split into orbital planes
+type Shell struct {
+	Altitude    float64 // km above the equatorial radius, mean of the satellites
+	Inclination float64 // degrees, mean of the satellites
+	Planes      []Plane // ordered by RAAN, their Satellites index the states given to Classify
+}
+
+// Classify groups satellites into shells by altitude and inclination, and the satellites of every shell into orbital planes
+// ordered by RAAN and argument of latitude at the time of the states, whose velocities must be known.
+// Shells are ordered by altitude. If mean is not nil it holds the mean elements of every satellite, whose semi-major axis
+// and inclination are used for the shells: the osculating ones of SGP4 states swing by several km over an orbit.
+func Classify(states []State, mean []Elements) []Shell {
+	altitudes := make([]float64, len(states))
+	inclinations := make([]float64, len(states))
+	normals := make([]Vector3, len(states))
+	for i, s := range states {
+		normals[i] = s.Position.Cross(s.Velocity).unit()
+		if mean != nil {
+			altitudes[i], inclinations[i] = mean[i].SemiMajorAxis-earthRadius, mean[i].Inclination*180/math.Pi
+			continue
+		}
+		// osculating semi-major axis from the vis-viva equation
+		radius := s.Position.magnitude()
+		altitudes[i] = 1/(2/radius-s.Velocity.Dot(s.Velocity)/mu) - earthRadius
+		inclinations[i] = math.Acos(math.Max(-1, math.Min(1, normals[i].Z))) * 180 / math.Pi
+	}
+
+	order := make([]int, len(states))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return altitudes[order[a]] < altitudes[order[b]] })
+	var shells []Shell
+	var members [][]int
+	for _, i := range order {
+		found := false
+		for s := range shells {
+			// the satellites come by altitude, the last member of a shell is the highest so far
+			highest := altitudes[members[s][len(members[s])-1]]
+			if altitudes[i]-highest <= ShellAltitudeTolerance && math.Abs(shells[s].Inclination-inclinations[i]) <= ShellInclinationTolerance {
+				n := float64(len(members[s]))
+				shells[s].Altitude = (shells[s].Altitude*n + altitudes[i]) / (n + 1)
+				shells[s].Inclination = (shells[s].Inclination*n + inclinations[i]) / (n + 1)
+				members[s] = append(members[s], i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			shells = append(shells, Shell{Altitude: altitudes[i], Inclination: inclinations[i]})
+			members = append(members, []int{i})
+		}
+	}
+
+	for s := range shells {
+		shellNormals := make([]Vector3, len(members[s]))
+		positions := make([]Vector3, len(members[s]))
+		for k, i := range members[s] {
+			shellNormals[k], positions[k] = normals[i], states[i].Position
+		}
+		shells[s].Planes = groupPlanes(shellNormals, positions)
+		for _, plane := range shells[s].Planes {
+			for k, local := range plane.Satellites {
+				plane.Satellites[k] = members[s][local]
+			}
+		}
+	}
+	sort.SliceStable(shells, func(a, b int) bool { return shells[a].Altitude < shells[b].Altitude })
+	return shells
+}
+
+// ClassifySets classifies satellites propagated from element sets with their states at t. ids and propagators are those
+// NewPropagators returned for the sets with setIds. The mean elements of the latest set of every satellite place it in a shell;
+// if a satellite has no set with readable mean elements, all satellites are classified by their osculating elements
+// and the ids of those without are returned.
+func ClassifySets(ids []int, propagators []Propagator, setIds []int, sets []gosat.Satellite, t time.Time) (shells []Shell, withoutMean []int) {
+	latest := make(map[int]Elements, len(ids))
+	for i, id := range setIds {
+		elements, err := ElementsFromTLE(sets[i])
+		if err != nil {
+			continue
+		}
+		if known, found := latest[id]; !found || elements.Epoch.After(known.Epoch) {
+			latest[id] = elements
+		}
+	}
+	states := make([]State, len(ids))
+	mean := make([]Elements, len(ids))
+	for i, id := range ids {
+		states[i].Position, states[i].Velocity = propagators[i].Propagate(t)
+		elements, found := latest[id]
+		if !found {
+			withoutMean = append(withoutMean, id)
+		}
+		mean[i] = elements
+	}
+	if withoutMean != nil {
+		mean = nil
+	}
+	return Classify(states, mean), withoutMean
+}
+
+// AssignPlanes sets Shell, Plane and Slot of the satellites, indexed like the states given to Classify.
+// Planes are numbered across shells, those of the lowest shell first.
+func AssignPlanes(satdata []OrbitalData, shells []Shell) {
+	plane := 0
+	for s, shell := range shells {
+		for _, p := range shell.Planes {
+			for slot, i := range p.Satellites {
+				satdata[i].Shell, satdata[i].Plane, satdata[i].Slot = s, plane, slot
+			}
+			plane++
+		}
+	}
+}
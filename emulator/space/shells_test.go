@@ -0,0 +1,189 @@
+package space
+
+import (
+	"math"
+	"math/rand"
+	"project/tle"
+	"testing"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// shellStates returns the states of a Walker star shell of planes*perPlane circular orbits at epoch,
+// half a spacing away from RAAN and argument of latitude 0 so the order of planes and slots is clear
+func shellStates(altitude, inclination float64, planes, perPlane int, epoch time.Time) []State {
+	var states []State
+	for p := 0; p < planes; p++ {
+		for s := 0; s < perPlane; s++ {
+			orbit := Kepler{Elements{
+				Epoch:         epoch,
+				SemiMajorAxis: earthRadius + altitude,
+				Inclination:   inclination * math.Pi / 180,
+				RAAN:          math.Pi * (float64(p) + 0.5) / float64(planes),
+				MeanAnomaly:   2 * math.Pi * (float64(s) + 0.5) / float64(perPlane),
+			}}
+			var state State
+			state.Position, state.Velocity = orbit.Propagate(epoch)
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+func TestClassify(t *testing.T) {
+	epoch := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	// two shells at the same inclination 10 km apart, and a polar one
+	states := append(shellStates(550, 53, 6, 10, epoch), shellStates(560, 53, 4, 8, epoch)...)
+	states = append(states, shellStates(1200, 87.9, 3, 12, epoch)...)
+	order := rand.New(rand.NewSource(1)).Perm(len(states))
+	shuffled := make([]State, len(states))
+	walkerIndex := make([]int, len(states))
+	for i, j := range order {
+		shuffled[j], walkerIndex[j] = states[i], i
+	}
+
+	shells := Classify(shuffled, nil)
+	expected := []struct {
+		altitude, inclination float64
+		planes, perPlane      int
+	}{{550, 53, 6, 10}, {560, 53, 4, 8}, {1200, 87.9, 3, 12}}
+	if len(shells) != len(expected) {
+		t.Fatalf("expected %d shells, got %d", len(expected), len(shells))
+	}
+	for s, e := range expected {
+		shell := shells[s]
+		if math.Abs(shell.Altitude-e.altitude) > 0.1 || math.Abs(shell.Inclination-e.inclination) > 0.01 {
+			t.Errorf("shell %d: expected %v km at %v degrees, got %.1f km at %.2f degrees", s, e.altitude, e.inclination, shell.Altitude, shell.Inclination)
+		}
+		if len(shell.Planes) != e.planes {
+			t.Errorf("shell %d: expected %d planes, got %d", s, e.planes, len(shell.Planes))
+			continue
+		}
+		for _, plane := range shell.Planes {
+			if len(plane.Satellites) != e.perPlane {
+				t.Errorf("shell %d: expected %d satellites per plane, got %d", s, e.perPlane, len(plane.Satellites))
+			}
+		}
+	}
+
+	satdata := make([]OrbitalData, len(shuffled))
+	AssignPlanes(satdata, shells)
+	for i, sat := range satdata {
+		// the Walker index of the satellite tells its shell, plane and slot
+		walker, shell, plane, slot := walkerIndex[i], 0, 0, 0
+		switch {
+		case walker < 60:
+			plane, slot = walker/10, walker%10
+		case walker < 92:
+			shell, plane, slot = 1, 6+(walker-60)/8, (walker-60)%8
+		default:
+			shell, plane, slot = 2, 10+(walker-92)/12, (walker-92)%12
+		}
+		if sat.Shell != shell || sat.Plane != plane || sat.Slot != slot {
+			t.Errorf("satellite %d: expected shell %d plane %d slot %d, got %d %d %d", i, shell, plane, slot, sat.Shell, sat.Plane, sat.Slot)
+		}
+	}
+}
+
+func TestClassifyOneWeb(t *testing.T) {
+	ids, sets, err := tle.LoadSatellites("../OneWeb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, propagators, err := NewPropagators(ModelSGP4, ids, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2022, 11, 17, 0, 0, 0, 0, time.UTC)
+	states := make([]State, len(ids))
+	mean := make([]Elements, len(ids))
+	for i := range ids {
+		states[i].Position, states[i].Velocity = propagators[i].Propagate(at)
+		if mean[i], err = ElementsFromTLE(sets[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shells := Classify(states, mean)
+	// satellites still raising their orbits form small shells below the operational one
+	operational := shells[len(shells)-1]
+	satellites := 0
+	for _, plane := range operational.Planes {
+		satellites += len(plane.Satellites)
+	}
+	if math.Abs(operational.Altitude-1200) > 20 || math.Abs(operational.Inclination-87.9) > 0.1 || satellites < 350 {
+		t.Errorf("expected most satellites at 1200 km and 87.9 degrees, got %d at %.0f km and %.2f degrees", satellites, operational.Altitude, operational.Inclination)
+	}
+	if len(operational.Planes) < 12 || len(operational.Planes) > 13 {
+		t.Errorf("expected the 12 planes of OneWeb, got %d", len(operational.Planes))
+	}
+}
+
+// elementSet returns the element set of a circular orbit at altitude km
+func elementSet(t *testing.T, id int, epoch time.Time, altitude, inclination, raan float64) gosat.Satellite {
+	a := earthRadius + altitude
+	omm := tle.OMM{
+		ObjectID:      "2023-001A",
+		Epoch:         epoch,
+		MeanMotion:    math.Sqrt(mu/(a*a*a)) * 86400 / (2 * math.Pi),
+		Eccentricity:  0.0001,
+		Inclination:   inclination,
+		RAAN:          raan,
+		CatalogNumber: id,
+		ElementSetNo:  999,
+	}
+	record, err := omm.TLE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record.Satellite()
+}
+
+func TestClassifySets(t *testing.T) {
+	epoch := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	older := epoch.Add(-10 * 24 * time.Hour)
+	// the latest sets put satellite 1 at 550 km and 53 degrees and satellite 2 at 1200 km and 87.9 degrees,
+	// the older ones both at 900 km
+	setIds := []int{2, 1, 1, 2}
+	sets := []gosat.Satellite{
+		elementSet(t, 2, epoch, 1200, 87.9, 10),
+		elementSet(t, 1, older, 900, 53, 0),
+		elementSet(t, 1, epoch, 550, 53, 0),
+		elementSet(t, 2, older, 900, 87.9, 10),
+	}
+	ids, propagators, err := NewPropagators(ModelSGP4, setIds, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shells, withoutMean := ClassifySets(ids, propagators, setIds, sets, epoch)
+	if withoutMean != nil {
+		t.Errorf("expected mean elements of every satellite, missing for %v", withoutMean)
+	}
+	if len(shells) != 2 {
+		t.Fatalf("expected 2 shells, got %d", len(shells))
+	}
+	for s, e := range []struct{ altitude, inclination float64 }{{550, 53}, {1200, 87.9}} {
+		if math.Abs(shells[s].Altitude-e.altitude) > 20 || math.Abs(shells[s].Inclination-e.inclination) > 0.01 {
+			t.Errorf("shell %d: expected %v km at %v degrees, got %.1f km at %.2f degrees", s, e.altitude, e.inclination, shells[s].Altitude, shells[s].Inclination)
+		}
+		if len(shells[s].Planes) != 1 || len(shells[s].Planes[0].Satellites) != 1 || shells[s].Planes[0].Satellites[0] != s {
+			t.Errorf("shell %d: expected satellite %d alone, got %+v", s, s, shells[s].Planes)
+		}
+	}
+}
+
+func TestClassifySetsWithoutMeanElements(t *testing.T) {
+	epoch := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	propagators := []Propagator{
+		Kepler{Elements{Epoch: epoch, SemiMajorAxis: earthRadius + 550, Inclination: 53 * math.Pi / 180}},
+		Kepler{Elements{Epoch: epoch, SemiMajorAxis: earthRadius + 1200, Inclination: 87.9 * math.Pi / 180}},
+	}
+	// sets without lines have no mean elements, the osculating elements of the states are used
+	shells, withoutMean := ClassifySets([]int{1, 2}, propagators, []int{1, 2}, make([]gosat.Satellite, 2), epoch)
+	if len(withoutMean) != 2 || withoutMean[0] != 1 || withoutMean[1] != 2 {
+		t.Errorf("expected 1 and 2 without mean elements, got %v", withoutMean)
+	}
+	if len(shells) != 2 || math.Abs(shells[0].Altitude-550) > 0.1 || math.Abs(shells[1].Altitude-1200) > 0.1 {
+		t.Errorf("expected shells at 550 and 1200 km, got %+v", shells)
+	}
+}
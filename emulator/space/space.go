@@ -21,6 +21,9 @@ const (
 	atmosphere float64 = 80   // Mesosphere
 )
 
+// DefaultGrazingHeight is the lowest altitude in km an inter-satellite link may pass, below it the atmosphere is too dense
+const DefaultGrazingHeight = atmosphere
+
 func (vector Vector3) AsgosatVector() gosat.Vector3 {
 	return gosat.Vector3{
 		X: vector.X,
@@ -40,17 +43,8 @@ func (vectorA Vector3) Speed() float64 {
 	return vectorA.magnitude()
 }
 
-func (vectorA Vector3) DopplerShift(vectorB Vector3) float64 {
-	v_o := vectorA.Speed()
-	v_s := vectorA.Sub(vectorB).Speed()
-	f_s := 430e12
-	v := C
-	f_o := ((v + v_o) / (v + v_s)) * f_s
-	return math.Abs(f_o - f_s)
-}
-
 func (vectorA Vector3) magnitude() float64 {
-	return math.Sqrt(vectorA.X*vectorA.X + vectorA.Y*vectorA.Y + vectorA.Z + vectorA.Z)
+	return math.Sqrt(vectorA.X*vectorA.X + vectorA.Y*vectorA.Y + vectorA.Z*vectorA.Z)
 }
 
 // h=350
@@ -58,6 +52,29 @@ func LineOfSight(satellite_height float64) float64 {
 	return math.Sqrt(math.Pow((r+satellite_height), 2)-math.Pow((r+atmosphere), 2)) * 2.0
 }
 
+// MinimumAltitude returns the lowest altitude in km above a spherical earth of the straight segment from p1 to p2
+func MinimumAltitude(p1, p2 Vector3) float64 {
+	d := p2.Sub(p1)
+	// the point of the segment closest to the centre of the earth, p1 + t*d with t clamped to the segment
+	t := 0.0
+	if length2 := d.Dot(d); length2 > 0 {
+		t = math.Max(0, math.Min(1, -p1.Dot(d)/length2))
+	}
+	closest := newVector(p1.X+t*d.X, p1.Y+t*d.Y, p1.Z+t*d.Z)
+	return closest.magnitude() - r
+}
+
+// InLineOfSight reports whether the segment between two satellites stays above grazingHeight km, so the earth
+// and its atmosphere do not block the link
+func InLineOfSight(p1, p2 Vector3, grazingHeight float64) bool {
+	return MinimumAltitude(p1, p2) >= grazingHeight
+}
+
+// AboveHorizon reports whether a satellite is above the geometric horizon of a point on the ground, both inertial positions
+func AboveHorizon(ground, satellite Vector3) bool {
+	return satellite.Sub(ground).Dot(ground) > 0
+}
+
 func (p1 Vector3) Distance(p2 Vector3) float64 {
 	return math.Sqrt(math.Pow(p1.X-p2.X, 2) + math.Pow(p1.Y-p2.Y, 2) + math.Pow(p1.Z-p2.Z, 2))
 }
@@ -96,6 +113,9 @@ type OrbitalData struct {
 	Isactive    bool   `parquet:"is_active"`
 	SatelliteId int    `parquet:"satellite_id"`
 	Title       string `parquet:"satellite_title"`
+	Shell       int    // shell of satellites at the same altitude and inclination, -1 if not known
+	Plane       int    // orbital plane, -1 if not known
+	Slot        int    // position in the plane, -1 if not known
 	Position    []Vector3
 	Velocity    []Vector3
 	LatLong     []LatLong
@@ -105,7 +125,7 @@ type OrbitalData struct {
 // for each satellite, calculate positions for duration of simulation
 func getSatPos(sat_channel <-chan satellite, satData chan<- OrbitalData, startTime time.Time, timestep time.Duration, duration time.Duration) {
 	for sat := range sat_channel {
-		var data OrbitalData
+		data := OrbitalData{Shell: -1, Plane: -1, Slot: -1}
 		data.Position = make([]Vector3, duration/timestep)
 		data.Velocity = make([]Vector3, duration/timestep)
 		data.LatLong = make([]LatLong, duration/timestep)
@@ -113,21 +133,14 @@ func getSatPos(sat_channel <-chan satellite, satData chan<- OrbitalData, startTi
 		for i := 0; i < int(duration)/int(timestep); i++ {
 			localStartTime = localStartTime.Add(1 * timestep)
 			// calculate position and velocity vectors of satellite for a given time
-			pos, vel := gosat.Propagate(sat.gosat, localStartTime.Year(), int(localStartTime.Month()), localStartTime.Day(), localStartTime.Hour(), localStartTime.Minute(), localStartTime.Second())
-
-			//jday := gosat.JDay(localStartTime.Year(), int(localStartTime.Month()), localStartTime.Day(), localStartTime.Hour(), localStartTime.Minute(), localStartTime.Second())
-			//gmst := gosat.ThetaG_JD(jday)
-
-			gst := gosat.GSTimeFromDate(localStartTime.Year(), int(localStartTime.Month()), localStartTime.Day(), localStartTime.Hour(), localStartTime.Minute(), localStartTime.Second())
-
-			_, _, ll := gosat.ECIToLLA(pos, gst)
-			//_, _, ll := gosat.ECIToLLA(pos, gmst)
+			pos, vel := sat.propagator.Propagate(localStartTime)
+			_, _, ll := gosat.ECIToLLA(pos.AsgosatVector(), GMST(localStartTime))
 
 			ll_deg := gosat.LatLongDeg(ll)
 			// altitude, velocity, ll := gosat.ECIToLLA(pos, gst)
 			//fmt.Println(pos)
-			data.Position[i] = Vector3{X: pos.X, Y: pos.Y, Z: pos.Z}
-			data.Velocity[i] = Vector3{X: vel.X, Y: vel.Y, Z: vel.Z}
+			data.Position[i] = pos
+			data.Velocity[i] = vel
 			data.LatLong[i] = LatLong{
 				Latitude:  ll_deg.Latitude,
 				Longitude: ll_deg.Longitude,
@@ -139,10 +152,8 @@ func getSatPos(sat_channel <-chan satellite, satData chan<- OrbitalData, startTi
 }
 
 func LLAFromPosition(pos Vector3, time time.Time) LatLong {
-	// Galileo System Time (i think)
-	gst := gosat.GSTimeFromDate(time.Year(), int(time.Month()), time.Day(), time.Hour(), time.Minute(), time.Second())
 	// Convert Earth Centered Inertial coordinated into equivalent LatLong (also returns altitude and velocity)
-	_, _, ll := gosat.ECIToLLA(pos.AsgosatVector(), gst)
+	_, _, ll := gosat.ECIToLLA(pos.AsgosatVector(), GMST(time))
 	// Convert LatLong in radians to LatLong in degrees
 	ll_deg := gosat.LatLongDeg(ll)
 	return LatLong{Latitude: ll_deg.Latitude, Longitude: ll_deg.Longitude}
@@ -165,22 +176,33 @@ func (satellites OrbitalDataByID) Less(i, j int) bool {
 
 type satellite struct {
 	satelliteId int `parquet:"satellite_id"`
-	gosat       gosat.Satellite
+	propagator  Propagator
+}
+
+// GetSatData propagates the satellites with SGP4. Element sets sharing an id are the history of one satellite,
+// every time is propagated from the set with the epoch closest to it.
+func GetSatData(satellites []gosat.Satellite, satelliteids []int, startTime time.Time, timestep time.Duration, duration time.Duration) ([]OrbitalData, error) {
+	ids, propagators, err := NewPropagators(ModelSGP4, satelliteids, satellites)
+	if err != nil {
+		return nil, err
+	}
+	return PropagateSatellites(propagators, ids, startTime, timestep, duration), nil
 }
 
-func GetSatData(satellites []gosat.Satellite, satelliteids []int, startTime time.Time, timestep time.Duration, duration time.Duration) (orbitalData []OrbitalData) {
+// PropagateSatellites calculates the positions of the satellites for the duration of the simulation with their propagators
+func PropagateSatellites(propagators []Propagator, satelliteids []int, startTime time.Time, timestep time.Duration, duration time.Duration) (orbitalData []OrbitalData) {
 
-	jobcount := len(satellites)
+	jobcount := len(propagators)
 	jobs := make(chan satellite, jobcount)
 	results := make(chan OrbitalData, jobcount)
 	// jobs <-chan int, results chan<- int
 	for w := 1; w <= runtime.NumCPU(); w++ {
 		go getSatPos(jobs, results, startTime, timestep, duration)
 	}
-	for i, gosat := range satellites {
+	for i, propagator := range propagators {
 		temporary_sat := satellite{
 			satelliteId: satelliteids[i],
-			gosat:       gosat,
+			propagator:  propagator,
 		}
 		jobs <- temporary_sat
 	}
@@ -1,6 +1,7 @@
 package space
 
 import (
+	"math"
 	"testing"
 )
 
@@ -8,6 +9,42 @@ func TestLineOfSight(t *testing.T) {
 
 }
 
+func TestInLineOfSight(t *testing.T) {
+	// two satellites at 550 km, 40 degrees apart: the line of sight dips to about 132 km
+	radius := r + 550
+	angle := 40 * math.Pi / 180
+	p1 := newVector(radius, 0, 0)
+	p2 := newVector(radius*math.Cos(angle), radius*math.Sin(angle), 0)
+	altitude := MinimumAltitude(p1, p2)
+	if expected := radius*math.Cos(angle/2) - r; math.Abs(altitude-expected) > 1e-9 {
+		t.Errorf("minimum altitude %f, expected %f", altitude, expected)
+	}
+	if !InLineOfSight(p1, p2, DefaultGrazingHeight) {
+		t.Error("link above the atmosphere is blocked")
+	}
+	if InLineOfSight(p1, p2, 150) {
+		t.Error("link below the grazing height is in sight")
+	}
+	// opposite sides of the earth
+	if InLineOfSight(p1, newVector(-radius, 0, 0), 0) {
+		t.Error("link through the earth is in sight")
+	}
+	// the closest point of a short segment is an end
+	if altitude := MinimumAltitude(p1, newVector(radius+100, 0, 0)); math.Abs(altitude-550) > 1e-9 {
+		t.Errorf("minimum altitude of a radial segment is %f", altitude)
+	}
+}
+
+func TestAboveHorizon(t *testing.T) {
+	ground := newVector(r, 0, 0)
+	if !AboveHorizon(ground, newVector(r+550, 1000, 0)) {
+		t.Error("satellite overhead is below the horizon")
+	}
+	if AboveHorizon(ground, newVector(r-100, 3000, 0)) {
+		t.Error("satellite behind the earth is above the horizon")
+	}
+}
+
 func TestDistance(t *testing.T) {
 
 	P1 := newVector(0, 0, 1)
@@ -93,16 +130,36 @@ func TestVectorOrder(t *testing.T) {
 	}
 }
 
-func TestDopplerShift(t *testing.T) {
-	A := Vector3{
-		0, 12, 0,
-	}
-	B := Vector3{
-		0, 0, 0,
-	}
-	shift := A.DopplerShift(B)
-	t.Log(shift)
-	if shift > 40e3 {
-		t.Fail()
+func TestOrbitalPlanes(t *testing.T) {
+	// 3 planes of 4 satellites at 1200 km, 60 degrees apart, the slots given out of order
+	var satdata []OrbitalData
+	for p := 0; p < 3; p++ {
+		raan := float64(p) * math.Pi / 3
+		for _, s := range []int{2, 0, 3, 1} {
+			sat := OrbitalData{SatelliteId: p*4 + s}
+			for i := 0; i < 2; i++ {
+				u := 0.1 + float64(s)*math.Pi/2 + 0.01*float64(i)
+				sat.Position = append(sat.Position, Vector3{
+					X: 7571 * (math.Cos(u)*math.Cos(raan) - math.Sin(u)*math.Sin(raan)*math.Cos(1.5)),
+					Y: 7571 * (math.Cos(u)*math.Sin(raan) + math.Sin(u)*math.Cos(raan)*math.Cos(1.5)),
+					Z: 7571 * math.Sin(u) * math.Sin(1.5),
+				})
+			}
+			satdata = append(satdata, sat)
+		}
+	}
+	planes := OrbitalPlanes(satdata, 0)
+	if len(planes) != 3 {
+		t.Fatalf("expected 3 planes, got %d", len(planes))
+	}
+	for p, plane := range planes {
+		if math.Abs(plane.RAAN-float64(p)*60) > 0.01 {
+			t.Errorf("plane %d has RAAN %g", p, plane.RAAN)
+		}
+		for slot, i := range plane.Satellites {
+			if satdata[i].SatelliteId != p*4+slot {
+				t.Errorf("plane %d slot %d holds satellite %d", p, slot, satdata[i].SatelliteId)
+			}
+		}
 	}
 }
@@ -0,0 +1,99 @@
+package space
+
+import (
+	"math"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+const (
+	AU        = 149597870.7 // km
+	sunRadius = 696000.0    // km
+)
+
+// SunPosition returns the position of the sun in km in the inertial frame at t,
+// from the low precision solar coordinates of the Astronomical Almanac, good to about 0.01 degrees
+func SunPosition(t time.Time) Vector3 {
+	centuries := (JulianDay(t) - 2451545.0) / 36525
+	degrees := math.Pi / 180
+	meanLongitude := 280.460 + 36000.771*centuries
+	meanAnomaly := (357.5291092 + 35999.05034*centuries) * degrees
+	longitude := (meanLongitude + 1.914666471*math.Sin(meanAnomaly) + 0.019994643*math.Sin(2*meanAnomaly)) * degrees
+	distance := (1.000140612 - 0.016708617*math.Cos(meanAnomaly) - 0.000139589*math.Cos(2*meanAnomaly)) * AU
+	obliquity := (23.439291 - 0.0130042*centuries) * degrees
+	return newVector(
+		distance*math.Cos(longitude),
+		distance*math.Cos(obliquity)*math.Sin(longitude),
+		distance*math.Sin(obliquity)*math.Sin(longitude),
+	)
+}
+
+// Illumination is how much of the sun a satellite sees
+type Illumination int
+
+const (
+	Sunlit   Illumination = iota
+	Penumbra              // the earth hides part of the sun
+	Umbra                 // the earth hides all of the sun
+)
+
+func (i Illumination) String() string {
+	switch i {
+	case Penumbra:
+		return "penumbra"
+	case Umbra:
+		return "umbra"
+	}
+	return "sunlit"
+}
+
+// Eclipse returns the illumination of a satellite at position with the sun at sun, from the cones of the earth's shadow
+func Eclipse(position, sun Vector3) Illumination {
+	toSun := sun.Sub(position)
+	toEarth := newVector(-position.X, -position.Y, -position.Z)
+	// apparent radii of the earth and the sun seen from the satellite
+	earthAngle := math.Asin(math.Min(1, r/position.magnitude()))
+	sunAngle := math.Asin(sunRadius / toSun.magnitude())
+	separation := angle(toSun, toEarth)
+	switch {
+	case separation >= earthAngle+sunAngle:
+		return Sunlit
+	case separation <= earthAngle-sunAngle:
+		return Umbra
+	}
+	return Penumbra
+}
+
+// Illuminations returns the illumination of every satellite with the sun at sun
+func Illuminations(states []State, sun Vector3) []Illumination {
+	illuminations := make([]Illumination, len(states))
+	for i, s := range states {
+		illuminations[i] = Eclipse(s.Position, sun)
+	}
+	return illuminations
+}
+
+// SunAngle returns the angle in degrees at from between the direction towards to and the direction towards the sun,
+// how far from the sun a terminal at from pointing at to looks
+func SunAngle(from, to, sun Vector3) float64 {
+	return angle(to.Sub(from), sun.Sub(from)) * 180 / math.Pi
+}
+
+// LinkSunAngle returns the sun exclusion angle of an optical link in degrees, the smaller SunAngle of its two terminals
+func LinkSunAngle(p1, p2, sun Vector3) float64 {
+	return math.Min(SunAngle(p1, p2, sun), SunAngle(p2, p1, sun))
+}
+
+// angle returns the angle in radians between a and b
+func angle(a, b Vector3) float64 {
+	cos := a.Dot(b) / (a.magnitude() * b.magnitude())
+	return math.Acos(math.Max(-1, math.Min(1, cos)))
+}
+
+// PositionAt returns the position of the ground station in km in the inertial frame at t, from its latitude and longitude
+func (gs GroundStation) PositionAt(t time.Time) Vector3 {
+	degrees := math.Pi / 180
+	position := gosat.LLAToECI(gosat.LatLong{Latitude: gs.Latlong.Latitude * degrees, Longitude: gs.Latlong.Longitude * degrees}, 0, JulianDay(t))
+	return newVector(position.X, position.Y, position.Z)
+}
@@ -0,0 +1,56 @@
+package space
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSunPosition(t *testing.T) {
+	// Vallado, Fundamentals of Astrodynamics, example 5-1: 2 April 2006 0:00 UTC
+	sun := SunPosition(time.Date(2006, 4, 2, 0, 0, 0, 0, time.UTC))
+	expected := newVector(0.9771945*AU, 0.1924424*AU, 0.0834308*AU)
+	if a := angle(sun, expected) * 180 / math.Pi; a > 0.01 {
+		t.Errorf("sun is %f degrees off", a)
+	}
+	if d := math.Abs(sun.magnitude()-expected.magnitude()) / AU; d > 1e-4 {
+		t.Errorf("sun distance is %f AU off", d)
+	}
+}
+
+func TestEclipse(t *testing.T) {
+	sun := newVector(AU, 0, 0)
+	for _, c := range []struct {
+		position Vector3
+		expected Illumination
+	}{
+		{newVector(7000, 0, 0), Sunlit},
+		{newVector(0, 7000, 0), Sunlit},
+		{newVector(-7000, 0, 0), Umbra},
+		{newVector(-7000, 6300, 0), Umbra},
+		// on the edge of the earth's shadow the sun is only partly hidden
+		{newVector(-7000, 6378, 0), Penumbra},
+		{newVector(-7000, 6450, 0), Sunlit},
+	} {
+		if got := Eclipse(c.position, sun); got != c.expected {
+			t.Errorf("%+v is %s, expected %s", c.position, got, c.expected)
+		}
+	}
+}
+
+func TestLinkSunAngle(t *testing.T) {
+	sun := newVector(AU, 0, 0)
+	p1, p2 := newVector(7000, 0, 0), newVector(8000, 0, 0)
+	if a := SunAngle(p1, p2, sun); a > 1e-3 {
+		t.Errorf("a terminal pointing at the sun looks %f degrees from it", a)
+	}
+	if a := SunAngle(p2, p1, sun); math.Abs(a-180) > 1e-3 {
+		t.Errorf("a terminal pointing away from the sun looks %f degrees from it", a)
+	}
+	if a := LinkSunAngle(p1, p2, sun); a > 1e-3 {
+		t.Errorf("link sun angle %f, expected the smaller of both terminals", a)
+	}
+	if a := LinkSunAngle(p1, newVector(7000, 1000, 0), sun); math.Abs(a-90) > 1e-3 {
+		t.Errorf("a link across the sun direction has sun angle %f", a)
+	}
+}
@@ -0,0 +1,59 @@
+package space
+
+import (
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// JulianDay returns the Julian date of t including the fraction of the day, to within about 40 microseconds
+func JulianDay(t time.Time) float64 {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return gosat.JDay(t.Year(), int(t.Month()), t.Day(), 0, 0, 0) + float64(t.Sub(midnight))/float64(24*time.Hour)
+}
+
+// GMST returns the Greenwich mean sidereal time at t in radians
+func GMST(t time.Time) float64 {
+	return gosat.ThetaG_JD(JulianDay(t))
+}
+
+// propagateSecond runs SGP4 at the whole second t
+func propagateSecond(sat gosat.Satellite, t time.Time) (Vector3, Vector3) {
+	pos, vel := gosat.Propagate(sat, t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second())
+	return Vector3{X: pos.X, Y: pos.Y, Z: pos.Z}, Vector3{X: vel.X, Y: vel.Y, Z: vel.Z}
+}
+
+// propagate returns the position in km and velocity in km/s of the satellite at t.
+// go-satellite only propagates whole seconds, so in between the state is interpolated with a cubic
+// Hermite spline through the whole seconds around t, which is exact to well below a millimetre over one second.
+func propagate(sat gosat.Satellite, t time.Time) (position, velocity Vector3) {
+	t = t.UTC()
+	before := t.Truncate(time.Second)
+	p0, v0 := propagateSecond(sat, before)
+	if before.Equal(t) {
+		return p0, v0
+	}
+	p1, v1 := propagateSecond(sat, before.Add(time.Second))
+	return hermite(p0, v0, p1, v1, 1, t.Sub(before).Seconds())
+}
+
+// hermite interpolates between position p0 with velocity v0 and p1 with v1 span seconds later
+// on the cubic Hermite spline through both, s is the fraction of span from p0, 0 to 1
+func hermite(p0, v0, p1, v1 Vector3, span, s float64) (position, velocity Vector3) {
+	s2, s3 := s*s, s*s*s
+	// the tangents of the unit spline are the velocities times span
+	h00, h10, h01, h11 := 2*s3-3*s2+1, (s3-2*s2+s)*span, -2*s3+3*s2, (s3-s2)*span
+	d00, d10, d01, d11 := (6*s2-6*s)/span, 3*s2-4*s+1, (-6*s2+6*s)/span, 3*s2-2*s
+	position = newVector(
+		h00*p0.X+h10*v0.X+h01*p1.X+h11*v1.X,
+		h00*p0.Y+h10*v0.Y+h01*p1.Y+h11*v1.Y,
+		h00*p0.Z+h10*v0.Z+h01*p1.Z+h11*v1.Z,
+	)
+	velocity = newVector(
+		d00*p0.X+d10*v0.X+d01*p1.X+d11*v1.X,
+		d00*p0.Y+d10*v0.Y+d01*p1.Y+d11*v1.Y,
+		d00*p0.Z+d10*v0.Z+d01*p1.Z+d11*v1.Z,
+	)
+	return position, velocity
+}
@@ -0,0 +1,68 @@
+package space
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+const (
+	issLine1 = "1 25544U 98067A   23001.50000000  .00016717  00000-0  30306-3 0  9993"
+	issLine2 = "2 25544  51.6416 339.8014 0005220  59.9580  56.5163 15.49937564375163"
+)
+
+func TestJulianDay(t *testing.T) {
+	j2000 := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	if jd := JulianDay(j2000); jd != 2451545.0 {
+		t.Errorf("J2000 is Julian day %f, expected 2451545", jd)
+	}
+	half := JulianDay(j2000.Add(500*time.Millisecond)) - 2451545.0
+	if math.Abs(half*86400-0.5) > 1e-4 {
+		t.Errorf("half a second after J2000 is %g seconds", half*86400)
+	}
+	local := j2000.In(time.FixedZone("CET", 3600))
+	if JulianDay(local) != 2451545.0 {
+		t.Error("Julian day depends on the time zone")
+	}
+}
+
+func TestGMSTMatchesWholeSeconds(t *testing.T) {
+	at := time.Date(2023, 1, 1, 13, 45, 12, 0, time.UTC)
+	gst := gosat.GSTimeFromDate(2023, 1, 1, 13, 45, 12)
+	if math.Abs(GMST(at)-gst) > 1e-6 {
+		t.Errorf("GMST %f differs from %f", GMST(at), gst)
+	}
+	// the earth turns about 7.3e-7 rad in 10 ms
+	if GMST(at.Add(10*time.Millisecond)) == GMST(at) {
+		t.Error("GMST does not change within a second")
+	}
+}
+
+func TestPropagateSubSecond(t *testing.T) {
+	sat := gosat.TLEToSat(issLine1, issLine2, gosat.GravityWGS72)
+	start := time.Date(2023, 1, 1, 13, 0, 0, 0, time.UTC)
+	previous, _ := propagate(sat, start)
+	for i := 1; i <= 100; i++ {
+		at := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		position, velocity := propagate(sat, at)
+		// at about 7.7 km/s a satellite moves about 77 m in 10 ms
+		moved := position.Distance(previous)
+		if math.Abs(moved-0.077) > 0.005 {
+			t.Fatalf("moved %f km in 10 ms at %s", moved, at)
+		}
+		if speed := math.Sqrt(velocity.Dot(velocity)); math.Abs(speed-7.66) > 0.1 {
+			t.Fatalf("speed %f km/s at %s", speed, at)
+		}
+		previous = position
+	}
+	// the spline passes through the whole seconds
+	exact, _ := propagateSecond(sat, start.Add(time.Second))
+	if position, _ := propagate(sat, start.Add(time.Second)); position != exact {
+		t.Error("propagate differs from SGP4 at a whole second")
+	}
+	if previous.Distance(exact) > 1e-9 {
+		t.Errorf("last interpolated position is %g km off", previous.Distance(exact))
+	}
+}
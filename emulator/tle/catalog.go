@@ -0,0 +1,234 @@
+package tle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// Client answers element set queries like the live Space-Track client, *gosat.Spacetrack and *Catalog both implement it
+type Client interface {
+	// GetTLE returns the satellite of the latest element set of catid with an epoch before ts
+	GetTLE(catid uint64, ts time.Time, gravConst gosat.Gravity) (gosat.Satellite, error)
+}
+
+var (
+	_ Client = (*gosat.Spacetrack)(nil)
+	_ Client = (*Catalog)(nil)
+)
+
+// ErrNotInCatalog is returned for objects, times and groups the catalogue holds no element sets for
+var ErrNotInCatalog = errors.New("not in the catalogue")
+
+const (
+	catalogIndex   = "index.json"
+	catalogVersion = 1 // bumped whenever the layout of the index changes
+)
+
+// Catalog is an offline element set catalogue: a directory of imported TLE and OMM snapshots
+// and an index of the element sets of every object in them, by epoch
+type Catalog struct {
+	Dir string
+
+	index  index
+	mu     sync.Mutex
+	loaded map[string][]TLE // snapshots read by lookups so far
+}
+
+type index struct {
+	Version   int                 `json:"version"`
+	Snapshots map[string]snapshot `json:"snapshots"` // by file name within Dir
+	Sets      map[int][]entry     `json:"sets"`      // by catalogue number, sorted by epoch
+	Groups    map[string][]int    `json:"groups"`    // catalogue numbers of the objects of every group, sorted
+}
+
+type snapshot struct {
+	Source   string    `json:"source"` // the path it was imported from
+	Group    string    `json:"group,omitempty"`
+	Imported time.Time `json:"imported"`
+}
+
+type entry struct {
+	Epoch time.Time `json:"epoch"`
+	File  string    `json:"file"`
+	Line  int       `json:"line"`
+}
+
+// OpenCatalog opens the catalogue in dir, an empty one if dir holds none yet
+func OpenCatalog(dir string) (*Catalog, error) {
+	c := &Catalog{Dir: dir, loaded: make(map[string][]TLE)}
+	c.index = index{Version: catalogVersion, Snapshots: make(map[string]snapshot), Sets: make(map[int][]entry), Groups: make(map[string][]int)}
+	data, err := os.ReadFile(filepath.Join(dir, catalogIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.index); err != nil {
+		return nil, fmt.Errorf("reading catalogue index of %s: %w", dir, err)
+	}
+	if c.index.Version != catalogVersion {
+		return nil, fmt.Errorf("catalogue %s has version %d, expected %d", dir, c.index.Version, catalogVersion)
+	}
+	return c, nil
+}
+
+// Import copies a TLE or OMM file into the catalogue as a snapshot of group, which may be empty, and indexes its element sets.
+// It returns the name of the snapshot and the number of element sets that were new to the catalogue;
+// importing the same contents again adds nothing.
+func (c *Catalog) Import(path, group string) (name string, added int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	records, err := ParseFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	sum := sha256.Sum256(data)
+	prefix := group
+	if prefix == "" {
+		prefix = "snapshot"
+	}
+	name = prefix + "-" + hex.EncodeToString(sum[:8]) + filepath.Ext(path)
+	if _, found := c.index.Snapshots[name]; found {
+		return name, 0, nil
+	}
+	if err := writeAtomically(filepath.Join(c.Dir, name), data); err != nil {
+		return "", 0, err
+	}
+
+	c.index.Snapshots[name] = snapshot{Source: path, Group: group, Imported: time.Now().UTC()}
+	members := make(map[int]bool)
+	for _, id := range c.index.Groups[group] {
+		members[id] = true
+	}
+	for _, record := range records {
+		if group != "" && !members[record.CatalogNumber] {
+			members[record.CatalogNumber] = true
+			c.index.Groups[group] = append(c.index.Groups[group], record.CatalogNumber)
+		}
+		sets := c.index.Sets[record.CatalogNumber]
+		known := false
+		for _, e := range sets {
+			known = known || e.Epoch.Equal(record.Epoch)
+		}
+		if known {
+			continue
+		}
+		sets = append(sets, entry{Epoch: record.Epoch, File: name, Line: record.Line})
+		sort.SliceStable(sets, func(i, j int) bool { return sets[i].Epoch.Before(sets[j].Epoch) })
+		c.index.Sets[record.CatalogNumber] = sets
+		added++
+	}
+	sort.Ints(c.index.Groups[group])
+	data, err = json.MarshalIndent(c.index, "", " ")
+	if err != nil {
+		return "", 0, err
+	}
+	return name, added, writeAtomically(filepath.Join(c.Dir, catalogIndex), data)
+}
+
+// writeAtomically replaces the file at path, so an interrupted import never leaves half a file
+func writeAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Lookup returns the latest element set of the object with catalogue number id with an epoch before at
+func (c *Catalog) Lookup(id int, at time.Time) (TLE, error) {
+	sets := c.index.Sets[id]
+	latest := sort.Search(len(sets), func(i int) bool { return !sets[i].Epoch.Before(at) }) - 1
+	if latest < 0 {
+		return TLE{}, fmt.Errorf("%w: no element set of %d before %s", ErrNotInCatalog, id, at.Format(time.RFC3339))
+	}
+	records, err := c.snapshot(sets[latest].File)
+	if err != nil {
+		return TLE{}, err
+	}
+	for _, record := range records {
+		if record.Line == sets[latest].Line && record.CatalogNumber == id {
+			return record, nil
+		}
+	}
+	return TLE{}, fmt.Errorf("snapshot %s no longer holds %d on line %d, import it again", sets[latest].File, id, sets[latest].Line)
+}
+
+func (c *Catalog) snapshot(name string) ([]TLE, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if records, found := c.loaded[name]; found {
+		return records, nil
+	}
+	records, err := ParseFile(filepath.Join(c.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	c.loaded[name] = records
+	return records, nil
+}
+
+// GetTLE implements Client
+func (c *Catalog) GetTLE(catid uint64, ts time.Time, gravConst gosat.Gravity) (gosat.Satellite, error) {
+	record, err := c.Lookup(int(catid), ts)
+	if err != nil {
+		return gosat.Satellite{}, err
+	}
+	return record.satellite(gravConst), nil
+}
+
+// Group returns the catalogue numbers of every object in the snapshots of group, sorted
+func (c *Catalog) Group(group string) ([]int, error) {
+	ids, found := c.index.Groups[group]
+	if !found {
+		return nil, fmt.Errorf("%w: no group %q", ErrNotInCatalog, group)
+	}
+	return append([]int(nil), ids...), nil
+}
+
+// Groups returns the names of the groups of the snapshots, sorted
+func (c *Catalog) Groups() []string {
+	groups := make([]string, 0, len(c.index.Groups))
+	for group := range c.index.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Objects returns the number of objects and of element sets in the catalogue
+func (c *Catalog) Objects() (objects, sets int) {
+	for _, s := range c.index.Sets {
+		objects++
+		sets += len(s)
+	}
+	return objects, sets
+}
@@ -0,0 +1,81 @@
+package tle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+func TestCatalog(t *testing.T) {
+	downloads, dir := t.TempDir(), t.TempDir()
+	first, second := filepath.Join(downloads, "first.tle"), filepath.Join(downloads, "second.json")
+	if err := os.WriteFile(first, []byte(withEpoch("001.50000000")+withEpoch("003.50000000")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(issJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := OpenCatalog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, added, err := catalog.Import(first, "stations"); err != nil || added != 2 {
+		t.Fatalf("expected 2 new element sets, got %d: %v", added, err)
+	}
+	// the JSON holds the set of day 1 again
+	if _, added, err := catalog.Import(second, ""); err != nil || added != 0 {
+		t.Fatalf("expected no new element sets, got %d: %v", added, err)
+	}
+	if _, added, err := catalog.Import(first, "stations"); err != nil || added != 0 {
+		t.Fatalf("expected importing again to add nothing, got %d: %v", added, err)
+	}
+
+	// a fresh catalogue reads the index from disk
+	reopened, err := OpenCatalog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var client Client = reopened
+	epoch := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	sat, err := client.GetTLE(25544, epoch.Add(24*time.Hour), gosat.GravityWGS84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sat.Line1 != withEpoch("001.50000000")[:lineLength] {
+		t.Errorf("expected the set of day 1, got %s", sat.Line1)
+	}
+	record, err := reopened.Lookup(25544, epoch.Add(72*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !record.Epoch.Equal(epoch.Add(48 * time.Hour)) {
+		t.Errorf("expected the set of day 3, got %s", record.Epoch)
+	}
+	if _, err := reopened.Lookup(25544, epoch); !errors.Is(err, ErrNotInCatalog) {
+		t.Errorf("expected no set before the first epoch, got %v", err)
+	}
+	if _, err := reopened.Lookup(7, epoch.Add(time.Hour)); !errors.Is(err, ErrNotInCatalog) {
+		t.Errorf("expected no set of an unknown object, got %v", err)
+	}
+
+	ids, err := reopened.Group("stations")
+	if err != nil || len(ids) != 1 || ids[0] != 25544 {
+		t.Errorf("expected stations to hold 25544, got %v: %v", ids, err)
+	}
+	if _, err := reopened.Group("starlink"); !errors.Is(err, ErrNotInCatalog) {
+		t.Errorf("expected an unknown group, got %v", err)
+	}
+	if objects, sets := reopened.Objects(); objects != 1 || sets != 2 {
+		t.Errorf("expected 1 object with 2 element sets, got %d with %d", objects, sets)
+	}
+
+	satellites, err := GetTLEfromNoradIDs(reopened, []int{25544}, epoch.Add(time.Hour))
+	if err != nil || len(satellites) != 1 {
+		t.Errorf("expected the ISS from the catalogue, got %d satellites: %v", len(satellites), err)
+	}
+}
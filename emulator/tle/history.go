@@ -0,0 +1,83 @@
+package tle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gosat "github.com/joshuaferrara/go-satellite"
+)
+
+// Files returns path if it is a file, or the files of the directory path sorted by name, leaving out hidden files and subdirectories
+func Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	return files, nil
+}
+
+// ParseHistory reads every element set of the files of path, a file or a directory, sorted by catalogue number and epoch.
+// Of two sets of one satellite with the same epoch the one read last is kept, so later snapshots in a directory win.
+func ParseHistory(path string) ([]TLE, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []TLE
+	for _, file := range files {
+		parsed, err := ParseFile(file)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, parsed...)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s holds no element sets", path)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].CatalogNumber != records[j].CatalogNumber {
+			return records[i].CatalogNumber < records[j].CatalogNumber
+		}
+		return records[i].Epoch.Before(records[j].Epoch)
+	})
+	kept := records[:0]
+	for _, record := range records {
+		if last := len(kept) - 1; last >= 0 && kept[last].CatalogNumber == record.CatalogNumber && kept[last].Epoch.Equal(record.Epoch) {
+			kept[last] = record
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept, nil
+}
+
+// LoadHistory reads a TLE history, a file or a directory of TLE and OMM files with any number of element sets per satellite,
+// and initialises SGP4 for every set. The ids are the NORAD catalogue numbers, one per set, so a satellite's id repeats once per epoch.
+func LoadHistory(path string) (satelliteIds []int, satellites []gosat.Satellite, err error) {
+	records, err := ParseHistory(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, record := range records {
+		satellites = append(satellites, record.Satellite())
+		satelliteIds = append(satelliteIds, record.CatalogNumber)
+	}
+	return satelliteIds, satellites, nil
+}
@@ -0,0 +1,55 @@
+package tle
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withEpoch returns the ISS elements at another epoch day of 2023
+func withEpoch(day string) string {
+	line1 := strings.Replace(issLine1, "23001.50000000", "23"+day, 1)
+	line1 = line1[:lineLength-1] + strconv.Itoa(Checksum(line1[:lineLength-1]))
+	return line1 + "\n" + issLine2 + "\n"
+}
+
+func TestLoadHistory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"2023-01-01.tle": withEpoch("001.50000000") + withEpoch("003.50000000"),
+		"2023-01-05.tle": withEpoch("003.50000000") + withEpoch("005.50000000"),
+		".hidden":        "not elements",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "older"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ParseHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected the 3 distinct epochs, got %d records", len(records))
+	}
+	for i, day := range []int{1, 3, 5} {
+		if epoch := time.Date(2023, 1, day, 12, 0, 0, 0, time.UTC); !records[i].Epoch.Equal(epoch) {
+			t.Errorf("record %d: expected epoch %s, got %s", i, epoch, records[i].Epoch)
+		}
+	}
+
+	ids, satellites, err := LoadHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || len(satellites) != 3 || ids[0] != 25544 || ids[2] != 25544 {
+		t.Errorf("expected 3 element sets of 25544, got %v", ids)
+	}
+}
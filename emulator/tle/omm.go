@@ -0,0 +1,430 @@
+package tle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the encoding of a file of element sets
+type Format int
+
+const (
+	FormatTLE  Format = iota
+	FormatJSON        // OMM in JSON as published by CelesTrak and Space-Track
+	FormatXML         // OMM in the CCSDS NDM/XML schema
+	FormatKVN         // OMM in CCSDS keyword = value notation
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "OMM JSON"
+	case FormatXML:
+		return "OMM XML"
+	case FormatKVN:
+		return "OMM KVN"
+	}
+	return "TLE"
+}
+
+// Detect returns the format of the contents of a file from its first characters
+func Detect(data []byte) Format {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	switch {
+	case len(data) == 0:
+		return FormatTLE
+	case data[0] == '[' || data[0] == '{':
+		return FormatJSON
+	case data[0] == '<':
+		return FormatXML
+	case bytes.HasPrefix(data, []byte("CCSDS_OMM_VERS")) || bytes.HasPrefix(data, []byte("COMMENT")):
+		return FormatKVN
+	}
+	return FormatTLE
+}
+
+// OMM is the SGP4 mean elements of one Orbit Mean-Elements Message, angles in degrees
+type OMM struct {
+	Line            int // line of the file the message starts on
+	ObjectName      string
+	ObjectID        string // international designator, 1998-067A
+	Epoch           time.Time
+	MeanMotion      float64 // revolutions per day
+	Eccentricity    float64
+	Inclination     float64
+	RAAN            float64
+	ArgOfPericenter float64
+	MeanAnomaly     float64
+	EphemerisType   int
+	Classification  byte
+	CatalogNumber   int
+	ElementSetNo    int
+	RevAtEpoch      int
+	BStar           float64 // 1/earth radii
+	MeanMotionDot   float64 // revolutions per day squared, halved as in the TLE format
+	MeanMotionDDot  float64 // revolutions per day cubed, divided by 6 as in the TLE format
+}
+
+// ParseOMM reads the messages of r in format, which must be one of the OMM formats.
+// file names r in the errors, which are *ParseError.
+func ParseOMM(r io.Reader, file string, format Format) ([]OMM, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	var records []fields
+	switch format {
+	case FormatJSON:
+		records, err = jsonFields(data, file)
+	case FormatXML:
+		records, err = xmlFields(data, file)
+	case FormatKVN:
+		records, err = kvnFields(data, file)
+	default:
+		return nil, fmt.Errorf("%s is not an OMM format", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]OMM, 0, len(records))
+	for _, record := range records {
+		message, err := record.omm()
+		if err != nil {
+			return nil, &ParseError{File: file, Line: record.line, Err: err}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// TLE returns the two line elements of the message, rounded to the precision of the TLE format
+func (o OMM) TLE() (TLE, error) {
+	catalog, err := formatCatalogNumber(o.CatalogNumber)
+	if err != nil {
+		return TLE{}, err
+	}
+	classification := o.Classification
+	if classification == 0 {
+		classification = 'U'
+	}
+	epoch := o.Epoch.UTC()
+	day := float64(epoch.Sub(time.Date(epoch.Year(), 1, 1, 0, 0, 0, 0, time.UTC)))/float64(24*time.Hour) + 1
+	line1 := fmt.Sprintf("1 %s%c %-8s %02d%012.8f %s %s %s %d %4d", catalog, classification, designator(o.ObjectID),
+		epoch.Year()%100, day, formatMeanMotionDot(o.MeanMotionDot), formatExponential(o.MeanMotionDDot), formatExponential(o.BStar),
+		o.EphemerisType%10, o.ElementSetNo%10000)
+	line2 := fmt.Sprintf("2 %s %8.4f %8.4f %07d %8.4f %8.4f %11.8f%5d", catalog, o.Inclination, o.RAAN,
+		int(math.Round(o.Eccentricity*1e7)), o.ArgOfPericenter, o.MeanAnomaly, o.MeanMotion, o.RevAtEpoch%100000)
+	line1 += strconv.Itoa(Checksum(line1))
+	line2 += strconv.Itoa(Checksum(line2))
+
+	t := TLE{Name: o.ObjectName, Line: o.Line}
+	if err := t.parseLine1(line1); err != nil {
+		return TLE{}, fmt.Errorf("elements of %d do not fit the TLE format: %w", o.CatalogNumber, err)
+	}
+	if err := t.parseLine2(line2); err != nil {
+		return TLE{}, fmt.Errorf("elements of %d do not fit the TLE format: %w", o.CatalogNumber, err)
+	}
+	// keep the exact values of the message
+	t.CatalogNumber, t.Epoch, t.BStar, t.MeanMotion = o.CatalogNumber, epoch, o.BStar, o.MeanMotion
+	return t, nil
+}
+
+// formatCatalogNumber writes a catalogue number in five characters, in the Alpha-5 format above 99999.
+// Numbers beyond Alpha-5 are written as 00000 like go-satellite is given them, the record keeps the number of the message.
+func formatCatalogNumber(number int) (string, error) {
+	if number < 0 {
+		return "", fmt.Errorf("%w: catalogue number %d does not fit the TLE format", ErrFormat, number)
+	}
+	if number > 339999 {
+		return "00000", nil
+	}
+	if number < 100000 {
+		return fmt.Sprintf("%05d", number), nil
+	}
+	letter := byte('A' + number/10000 - 10)
+	if letter >= 'I' {
+		letter++
+	}
+	if letter >= 'O' {
+		letter++
+	}
+	return fmt.Sprintf("%c%04d", letter, number%10000), nil
+}
+
+// designator shortens an international designator such as 1998-067A to the 98067A of the TLE format
+func designator(id string) string {
+	if len(id) > 5 && id[4] == '-' {
+		id = id[2:4] + id[5:]
+	}
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return id
+}
+
+// formatMeanMotionDot writes the first derivative of the mean motion as " .00016717"
+func formatMeanMotionDot(value float64) string {
+	sign := " "
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	return sign + strings.TrimPrefix(fmt.Sprintf("%.8f", math.Min(value, 0.99999999)), "0")
+}
+
+// formatExponential writes value in the assumed decimal point notation of the TLE format, the inverse of exponential
+func formatExponential(value float64) string {
+	sign := byte(' ')
+	if value < 0 {
+		sign, value = '-', -value
+	}
+	if value == 0 {
+		return " 00000-0"
+	}
+	exponent := int(math.Floor(math.Log10(value))) + 1
+	digits := int(math.Round(value / math.Pow10(exponent) * 1e5))
+	if digits >= 100000 {
+		digits, exponent = digits/10, exponent+1
+	}
+	switch {
+	case exponent < -9:
+		return " 00000-0"
+	case exponent > 9:
+		digits, exponent = 99999, 9
+	}
+	exponentSign := byte('+')
+	if exponent < 0 {
+		exponentSign, exponent = '-', -exponent
+	}
+	return fmt.Sprintf("%c%05d%c%d", sign, digits, exponentSign, exponent)
+}
+
+// fields are the keywords of one message and the line it starts on
+type fields struct {
+	values map[string]string
+	line   int
+}
+
+func newFields(line int) fields {
+	return fields{values: map[string]string{}, line: line}
+}
+
+func (f fields) omm() (OMM, error) {
+	o := OMM{Line: f.line}
+	var err error
+	text := func(key string) string {
+		return f.values[key]
+	}
+	number := func(key string, required bool, into *float64) {
+		value, found := f.values[key]
+		if err != nil || (!found && !required) {
+			return
+		}
+		if !found {
+			err = fmt.Errorf("%w: missing %s", ErrFormat, key)
+			return
+		}
+		if *into, err = strconv.ParseFloat(value, 64); err != nil {
+			err = fmt.Errorf("%w: %s %q is not a number", ErrFormat, key, value)
+		}
+	}
+	integer := func(key string, required bool, into *int) {
+		value := float64(*into)
+		number(key, required, &value)
+		if err == nil && value != math.Trunc(value) {
+			err = fmt.Errorf("%w: %s %v is not an integer", ErrFormat, key, value)
+		}
+		*into = int(value)
+	}
+
+	if theory := strings.ToUpper(text("MEAN_ELEMENT_THEORY")); theory != "" && theory != "SGP4" {
+		return OMM{}, fmt.Errorf("%w: mean element theory %s is not SGP4", ErrFormat, theory)
+	}
+	o.ObjectName, o.ObjectID = text("OBJECT_NAME"), text("OBJECT_ID")
+	if classification := text("CLASSIFICATION_TYPE"); classification != "" {
+		o.Classification = classification[0]
+	}
+	o.ElementSetNo = 999
+	integer("NORAD_CAT_ID", true, &o.CatalogNumber)
+	number("MEAN_MOTION", true, &o.MeanMotion)
+	number("ECCENTRICITY", true, &o.Eccentricity)
+	number("INCLINATION", true, &o.Inclination)
+	number("RA_OF_ASC_NODE", true, &o.RAAN)
+	number("ARG_OF_PERICENTER", true, &o.ArgOfPericenter)
+	number("MEAN_ANOMALY", true, &o.MeanAnomaly)
+	integer("EPHEMERIS_TYPE", false, &o.EphemerisType)
+	integer("ELEMENT_SET_NO", false, &o.ElementSetNo)
+	integer("REV_AT_EPOCH", false, &o.RevAtEpoch)
+	number("BSTAR", false, &o.BStar)
+	number("MEAN_MOTION_DOT", false, &o.MeanMotionDot)
+	number("MEAN_MOTION_DDOT", false, &o.MeanMotionDDot)
+	if err != nil {
+		return OMM{}, err
+	}
+	if o.Epoch, err = parseEpoch(text("EPOCH")); err != nil {
+		return OMM{}, err
+	}
+	if o.MeanMotion <= 0 || o.Eccentricity < 0 || o.Eccentricity >= 1 {
+		return OMM{}, fmt.Errorf("%w: %d has mean motion %v and eccentricity %v", ErrFormat, o.CatalogNumber, o.MeanMotion, o.Eccentricity)
+	}
+	return o, nil
+}
+
+// parseEpoch reads a CCSDS UTC time, 2023-01-01T12:00:00.000 or by day of year 2023-001T12:00:00.000, with an optional Z
+func parseEpoch(value string) (time.Time, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "Z")
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%w: missing EPOCH", ErrFormat)
+	}
+	if epoch, err := time.Parse("2006-01-02T15:04:05.999999999", value); err == nil {
+		return epoch, nil
+	}
+	// day of year
+	if len(value) > 9 && value[4] == '-' && value[8] == 'T' {
+		year, yearErr := strconv.Atoi(value[:4])
+		day, dayErr := strconv.Atoi(value[5:8])
+		clock, clockErr := time.Parse("15:04:05.999999999", value[9:])
+		if yearErr == nil && dayErr == nil && clockErr == nil && day >= 1 && day <= 366 {
+			return time.Date(year, 1, day, clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: EPOCH %q", ErrFormat, value)
+}
+
+// jsonFields reads an array of messages, or a single message, each a flat object of CCSDS keywords.
+// Space-Track quotes the numbers, CelesTrak does not.
+func jsonFields(data []byte, file string) ([]fields, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	fail := func(err error) ([]fields, error) {
+		return nil, &ParseError{File: file, Line: lineAt(data, decoder.InputOffset()), Err: fmt.Errorf("%w: %v", ErrFormat, err)}
+	}
+	decode := func() (fields, error) {
+		record := newFields(lineAt(data, decoder.InputOffset()))
+		var values map[string]interface{}
+		if err := decoder.Decode(&values); err != nil {
+			return record, err
+		}
+		for key, value := range values {
+			if value != nil {
+				record.values[strings.ToUpper(key)] = strings.TrimSpace(fmt.Sprint(value))
+			}
+		}
+		return record, nil
+	}
+
+	if start := bytes.TrimLeft(data, " \t\r\n\xef\xbb\xbf"); len(start) > 0 && start[0] == '{' {
+		record, err := decode()
+		if err != nil {
+			return fail(err)
+		}
+		return []fields{record}, nil
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fail(err)
+	}
+	var records []fields
+	for decoder.More() {
+		record, err := decode()
+		if err != nil {
+			return fail(err)
+		}
+		records = append(records, record)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fail(err)
+	}
+	return records, nil
+}
+
+// xmlFields reads the omm elements of an ndm document, or a single omm document, taking the text of every leaf element
+func xmlFields(data []byte, file string) ([]fields, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var records []fields
+	var record *fields
+	var text strings.Builder
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ParseError{File: file, Line: lineAt(data, decoder.InputOffset()), Err: fmt.Errorf("%w: %v", ErrFormat, err)}
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "omm" {
+				started := newFields(lineAt(data, offset))
+				record = &started
+			}
+			text.Reset()
+		case xml.CharData:
+			text.Write(token)
+		case xml.EndElement:
+			switch {
+			case record == nil:
+			case token.Name.Local == "omm":
+				records = append(records, *record)
+				record = nil
+			case strings.TrimSpace(text.String()) != "":
+				record.values[strings.ToUpper(token.Name.Local)] = strings.TrimSpace(text.String())
+			}
+			text.Reset()
+		}
+	}
+	return records, nil
+}
+
+// kvnFields reads "KEYWORD = value [unit]" lines, every CCSDS_OMM_VERS starting a message
+func kvnFields(data []byte, file string) ([]fields, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var records []fields
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, &ParseError{File: file, Line: number, Err: fmt.Errorf("%w: %q is not KEYWORD = value", ErrFormat, line)}
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if unit := strings.Index(value, "["); unit >= 0 {
+			value = value[:unit]
+		}
+		if key == "CCSDS_OMM_VERS" {
+			records = append(records, newFields(number))
+		}
+		if len(records) == 0 {
+			return nil, &ParseError{File: file, Line: number, Err: fmt.Errorf("%w: %s before CCSDS_OMM_VERS", ErrFormat, key)}
+		}
+		records[len(records)-1].values[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	return records, nil
+}
+
+// lineAt returns the line of the first character at or after offset that is not a separator, counting from 1
+func lineAt(data []byte, offset int64) int {
+	end := int(offset)
+	for end < len(data) && strings.IndexByte(" \t\r\n,", data[end]) >= 0 {
+		end++
+	}
+	if end > len(data) {
+		end = len(data)
+	}
+	return bytes.Count(data[:end], []byte("\n")) + 1
+}
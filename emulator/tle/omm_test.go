@@ -0,0 +1,209 @@
+package tle
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const issJSON = `[{
+  "OBJECT_NAME": "ISS (ZARYA)",
+  "OBJECT_ID": "1998-067A",
+  "EPOCH": "2023-01-01T12:00:00.000000",
+  "MEAN_MOTION": 15.49937564,
+  "ECCENTRICITY": 0.000522,
+  "INCLINATION": 51.6416,
+  "RA_OF_ASC_NODE": 339.8014,
+  "ARG_OF_PERICENTER": 59.958,
+  "MEAN_ANOMALY": 56.5163,
+  "EPHEMERIS_TYPE": 0,
+  "CLASSIFICATION_TYPE": "U",
+  "NORAD_CAT_ID": 25544,
+  "ELEMENT_SET_NO": 999,
+  "REV_AT_EPOCH": 37516,
+  "BSTAR": 0.00030306,
+  "MEAN_MOTION_DOT": 0.00016717,
+  "MEAN_MOTION_DDOT": 0
+}]`
+
+const issSpaceTrackJSON = `[{"CCSDS_OMM_VERS":"2.0","OBJECT_NAME":"ISS (ZARYA)","OBJECT_ID":"1998-067A","MEAN_ELEMENT_THEORY":"SGP4",` +
+	`"EPOCH":"2023-01-01T12:00:00.000000","MEAN_MOTION":"15.49937564","ECCENTRICITY":"0.00052200","INCLINATION":"51.6416",` +
+	`"RA_OF_ASC_NODE":"339.8014","ARG_OF_PERICENTER":"59.9580","MEAN_ANOMALY":"56.5163","EPHEMERIS_TYPE":"0","CLASSIFICATION_TYPE":"U",` +
+	`"NORAD_CAT_ID":"25544","ELEMENT_SET_NO":"999","REV_AT_EPOCH":"37516","BSTAR":"0.00030306000000","MEAN_MOTION_DOT":"0.00016717",` +
+	`"MEAN_MOTION_DDOT":"0.0000000000000","DECAYED":null}]`
+
+const issXML = `<?xml version="1.0" encoding="UTF-8"?>
+<ndm xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<omm id="CCSDS_OMM_VERS" version="2.0">
+<header><CREATION_DATE/><ORIGINATOR/></header>
+<body><segment>
+<metadata>
+<OBJECT_NAME>ISS (ZARYA)</OBJECT_NAME><OBJECT_ID>1998-067A</OBJECT_ID><CENTER_NAME>EARTH</CENTER_NAME>
+<REF_FRAME>TEME</REF_FRAME><TIME_SYSTEM>UTC</TIME_SYSTEM><MEAN_ELEMENT_THEORY>SGP4</MEAN_ELEMENT_THEORY>
+</metadata>
+<data>
+<meanElements><EPOCH>2023-01-01T12:00:00.000000</EPOCH><MEAN_MOTION>15.49937564</MEAN_MOTION><ECCENTRICITY>.000522</ECCENTRICITY>
+<INCLINATION>51.6416</INCLINATION><RA_OF_ASC_NODE>339.8014</RA_OF_ASC_NODE><ARG_OF_PERICENTER>59.958</ARG_OF_PERICENTER>
+<MEAN_ANOMALY>56.5163</MEAN_ANOMALY></meanElements>
+<tleParameters><EPHEMERIS_TYPE>0</EPHEMERIS_TYPE><CLASSIFICATION_TYPE>U</CLASSIFICATION_TYPE><NORAD_CAT_ID>25544</NORAD_CAT_ID>
+<ELEMENT_SET_NO>999</ELEMENT_SET_NO><REV_AT_EPOCH>37516</REV_AT_EPOCH><BSTAR>.30306E-3</BSTAR>
+<MEAN_MOTION_DOT>.16717E-3</MEAN_MOTION_DOT><MEAN_MOTION_DDOT>0</MEAN_MOTION_DDOT></tleParameters>
+</data>
+</segment></body>
+</omm>
+</ndm>`
+
+const issKVN = `CCSDS_OMM_VERS = 2.0
+COMMENT from a CelesTrak snapshot
+OBJECT_NAME = ISS (ZARYA)
+OBJECT_ID = 1998-067A
+MEAN_ELEMENT_THEORY = SGP4
+EPOCH = 2023-001T12:00:00.000
+MEAN_MOTION = 15.49937564 [rev/day]
+ECCENTRICITY = .000522
+INCLINATION = 51.6416 [deg]
+RA_OF_ASC_NODE = 339.8014 [deg]
+ARG_OF_PERICENTER = 59.958 [deg]
+MEAN_ANOMALY = 56.5163 [deg]
+EPHEMERIS_TYPE = 0
+CLASSIFICATION_TYPE = U
+NORAD_CAT_ID = 25544
+ELEMENT_SET_NO = 999
+REV_AT_EPOCH = 37516
+BSTAR = .30306E-3 [1/ER]
+MEAN_MOTION_DOT = .16717E-3 [rev/day**2]
+MEAN_MOTION_DDOT = 0 [rev/day**3]
+`
+
+func TestDetect(t *testing.T) {
+	cases := map[Format]string{
+		FormatJSON: issJSON,
+		FormatXML:  issXML,
+		FormatKVN:  issKVN,
+		FormatTLE:  "ISS (ZARYA)\n" + issLine1 + "\n" + issLine2 + "\n",
+	}
+	for expected, data := range cases {
+		if format := Detect([]byte("\n" + data)); format != expected {
+			t.Errorf("expected %s, got %s", expected, format)
+		}
+	}
+}
+
+func TestParseOMM(t *testing.T) {
+	cases := map[string]struct {
+		data   string
+		format Format
+	}{
+		"CelesTrak JSON":   {issJSON, FormatJSON},
+		"Space-Track JSON": {issSpaceTrackJSON, FormatJSON},
+		"single object":    {strings.Trim(issJSON, "[]"), FormatJSON},
+		"XML":              {issXML, FormatXML},
+		"KVN":              {issKVN, FormatKVN},
+	}
+	for name, c := range cases {
+		messages, err := ParseOMM(strings.NewReader(c.data), "iss", c.format)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(messages) != 1 {
+			t.Errorf("%s: expected 1 message, got %d", name, len(messages))
+			continue
+		}
+		record, err := messages[0].TLE()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if record.Line1 != issLine1 || record.Line2 != issLine2 {
+			t.Errorf("%s: expected\n%s\n%s\ngot\n%s\n%s", name, issLine1, issLine2, record.Line1, record.Line2)
+		}
+		if record.Name != "ISS (ZARYA)" || record.CatalogNumber != 25544 {
+			t.Errorf("%s: expected ISS (ZARYA) 25544, got %s %d", name, record.Name, record.CatalogNumber)
+		}
+	}
+}
+
+func TestParseOMMErrors(t *testing.T) {
+	cases := map[string]struct {
+		data   string
+		format Format
+		line   int
+	}{
+		"number":        {strings.Replace(issKVN, "= 51.6416", "= fifty", 1), FormatKVN, 1},
+		"missing":       {strings.Replace(issJSON, `"MEAN_MOTION": 15.49937564,`, "", 1), FormatJSON, 1},
+		"second record": {"[" + strings.Trim(issJSON, "[]") + ",\n{\"NORAD_CAT_ID\": 1}]", FormatJSON, 20},
+		"theory":        {strings.Replace(issXML, ">SGP4<", ">SDP4<", 1), FormatXML, 3},
+		"no keyword":    {issKVN + "DECAYED\n", FormatKVN, 21},
+	}
+	for name, c := range cases {
+		_, err := ParseOMM(strings.NewReader(c.data), "iss", c.format)
+		var parseErr *ParseError
+		if !errors.Is(err, ErrFormat) || !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a format error, got %v", name, err)
+		} else if parseErr.Line != c.line {
+			t.Errorf("%s: expected line %d, got %s", name, c.line, err)
+		}
+	}
+}
+
+func TestOMMAlpha5(t *testing.T) {
+	messages, err := ParseOMM(strings.NewReader(strings.Replace(issJSON, "25544", "270000", 1)), "iss", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := messages[0].TLE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.CatalogNumber != 270000 || record.Line1[2:7] != "T0000" {
+		t.Errorf("expected 270000 as T0000, got %d as %s", record.CatalogNumber, record.Line1[2:7])
+	}
+	if sat := record.Satellite(); sat.Line1 != record.Line1 {
+		t.Errorf("expected the satellite to keep its lines, got %s", sat.Line1)
+	}
+}
+
+func TestLoadSatellitesOMM(t *testing.T) {
+	filename := t.TempDir() + "/iss.json"
+	if err := os.WriteFile(filename, []byte(issJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ids, satellites, err := LoadSatellites(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 25544 || satellites[0].Line2 != issLine2 {
+		t.Errorf("expected the ISS, got %v", ids)
+	}
+}
+
+func TestLoadSatellitesOMMBeyondAlpha5(t *testing.T) {
+	filename := t.TempDir() + "/object.json"
+	if err := os.WriteFile(filename, []byte(strings.Replace(issJSON, "25544", "1234567", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ids, satellites, err := LoadSatellites(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 1234567 {
+		t.Fatalf("expected 1234567, got %v", ids)
+	}
+	if satellites[0].Line1[2:7] != "00000" || satellites[0].Line2[2:7] != "00000" {
+		t.Errorf("expected 00000 in the lines, got %s and %s", satellites[0].Line1, satellites[0].Line2)
+	}
+}
+
+func TestFormatExponential(t *testing.T) {
+	for _, field := range []string{" 30306-3", "-34415-4", " 00000-0", " 12345+1", " 10000-9"} {
+		value, err := exponential(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if formatted := formatExponential(value); formatted != field {
+			t.Errorf("expected %q, got %q", field, formatted)
+		}
+	}
+}
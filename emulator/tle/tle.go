@@ -2,102 +2,312 @@ package tle
 
 import (
 	"bufio"
-	"math/rand"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	gosat "github.com/joshuaferrara/go-satellite"
-	"github.com/rs/zerolog/log"
 )
 
-type TLEdata struct {
-	Title string `parquet:"title_line"`
-	Line1 string `parquet:"l1"`
-	Line2 string `parquet:"l2"`
+// lineLength is the length of both lines of a two line element set including the checksum
+const lineLength = 69
+
+var (
+	ErrFormat     = errors.New("malformed two line elements")
+	ErrLineNumber = errors.New("wrong line number")
+	ErrChecksum   = errors.New("checksum mismatch")
+	ErrDuplicate  = errors.New("duplicate catalogue number")
+)
+
+// ParseError is a malformed record, Line is the line of File it was found on, counting from 1
+type ParseError struct {
+	File string
+	Line int
+	Err  error
 }
 
-func loadTLEdata(filename string) (satellites []TLEdata, found bool) {
-	var lines []string
-	f, err := os.Open(filename)
-	if err != nil {
-		return satellites, false
-	}
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
 
-	fileScanner := bufio.NewScanner(f)
-	fileScanner.Split(bufio.ScanLines)
+// TLE is one two line element set with the fields the emulator uses
+type TLE struct {
+	Name          string // title line, empty if the record has none
+	Line1         string
+	Line2         string
+	Line          int       // line of the file the record starts on
+	CatalogNumber int       // NORAD catalogue number
+	Epoch         time.Time // UTC
+	BStar         float64   // drag term in 1/earth radii
+	MeanMotion    float64   // revolutions per day
+}
+
+// Satellite initialises SGP4 for the record
+func (t TLE) Satellite() gosat.Satellite {
+	return t.satellite(gosat.GravityWGS84)
+}
 
-	for i := 0; fileScanner.Scan(); i++ {
-		lines = append(lines, fileScanner.Text())
+func (t TLE) satellite(gravity gosat.Gravity) gosat.Satellite {
+	line1, line2 := t.Line1, t.Line2
+	// go-satellite only reads numeric catalogue numbers, which SGP4 does not use
+	if c := line1[2]; c < '0' || c > '9' {
+		line1, line2 = line1[:2]+"00000"+line1[7:], line2[:2]+"00000"+line2[7:]
 	}
-	defer f.Close()
+	sat := gosat.TLEToSat(line1, line2, gravity)
+	sat.Line1, sat.Line2 = t.Line1, t.Line2
+	return sat
+}
 
-	if len(lines)%3 != 0 {
-		log.Warn().Msg("Lines should be a multiple of 3")
+// Parse reads the records of r, with or without title lines. Blank lines are skipped and a "0 " in front of a title is dropped.
+// file names r in the errors, which are *ParseError; parsing stops at the first malformed record.
+func Parse(r io.Reader, file string) (records []TLE, err error) {
+	scanner := bufio.NewScanner(r)
+	var name string
+	nameLine, number := 0, 0
+	var pending *TLE // a record waiting for its second line
+	fail := func(line int, err error) ([]TLE, error) {
+		return nil, &ParseError{File: file, Line: line, Err: err}
+	}
+	for scanner.Scan() {
+		number++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case pending != nil:
+			if err := pending.parseLine2(line); err != nil {
+				return fail(number, err)
+			}
+			records = append(records, *pending)
+			pending, name = nil, ""
+		case strings.HasPrefix(line, "1 "):
+			record := TLE{Name: name, Line: number}
+			if name != "" {
+				record.Line = nameLine
+			}
+			if err := record.parseLine1(line); err != nil {
+				return fail(number, err)
+			}
+			pending = &record
+		case strings.HasPrefix(line, "2 "):
+			return fail(number, fmt.Errorf("%w: line 2 without line 1", ErrLineNumber))
+		default:
+			if name != "" {
+				return fail(nameLine, fmt.Errorf("%w: title %q is not followed by line 1", ErrFormat, name))
+			}
+			name, nameLine = strings.TrimSpace(strings.TrimPrefix(line, "0 ")), number
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	if pending != nil {
+		return fail(number, fmt.Errorf("%w: line 1 without line 2 at the end of the file", ErrLineNumber))
 	}
+	if name != "" {
+		return fail(nameLine, fmt.Errorf("%w: title %q at the end of the file", ErrFormat, name))
+	}
+	return records, nil
+}
 
-	for i := 0; i < len(lines)-2; i += 3 {
-		satellites = append(satellites, TLEdata{
-			Title: lines[i],
-			Line1: lines[i+1],
-			Line2: lines[i+2],
-		})
+// ParseFile parses the records of a file in any Format, detected from its contents. OMM messages are converted to two line elements.
+func ParseFile(filename string) ([]TLE, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	format := Detect(data)
+	if format == FormatTLE {
+		return Parse(bytes.NewReader(data), filename)
 	}
-	return satellites, true
+	messages, err := ParseOMM(bytes.NewReader(data), filename, format)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]TLE, 0, len(messages))
+	for _, message := range messages {
+		record, err := message.TLE()
+		if err != nil {
+			return nil, &ParseError{File: filename, Line: message.Line, Err: err}
+		}
+		records = append(records, record)
+	}
+	return records, nil
 }
 
-func idFromTitleLine(title string) int {
-	var id_string string = title
-	if strings.HasPrefix(title, "STARLINK-") {
-		id_string = strings.TrimPrefix(title, "STARLINK-")
+// checkLine verifies the length, line number and checksum of one line of a record
+func checkLine(line string, number byte) error {
+	if len(line) != lineLength {
+		return fmt.Errorf("%w: line %c has %d characters, expected %d", ErrFormat, number, len(line), lineLength)
+	}
+	if line[0] != number || line[1] != ' ' {
+		return fmt.Errorf("%w: expected line %c, got %q", ErrLineNumber, number, line[:2])
+	}
+	expected := int(line[lineLength-1] - '0')
+	if expected < 0 || expected > 9 {
+		return fmt.Errorf("%w: checksum %q is not a digit", ErrFormat, line[lineLength-1])
+	}
+	if sum := Checksum(line[:lineLength-1]); sum != expected {
+		return fmt.Errorf("%w: line %c sums to %d, its checksum is %d", ErrChecksum, number, sum, expected)
 	}
-	if strings.HasPrefix(title, "ONEWEB-") {
-		id_string = strings.TrimPrefix(title, "ONEWEB-")
+	return nil
+}
+
+// Checksum is the modulo 10 sum of the digits of a line, minus signs counting as 1
+func Checksum(line string) int {
+	sum := 0
+	for _, c := range line {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
 	}
+	return sum % 10
+}
 
-	id_string = strings.TrimSpace(id_string)
-	id_string = strings.TrimSuffix(id_string, " (DARKSAT)")
-	id_string = strings.TrimSuffix(id_string, " (VISORSAT)")
+func (t *TLE) parseLine1(line string) (err error) {
+	if err := checkLine(line, '1'); err != nil {
+		return err
+	}
+	t.Line1 = line
+	if t.CatalogNumber, err = catalogNumber(line[2:7]); err != nil {
+		return err
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(line[18:20]))
+	if err != nil {
+		return fmt.Errorf("%w: epoch year %q", ErrFormat, line[18:20])
+	}
+	day, err := strconv.ParseFloat(strings.TrimSpace(line[20:32]), 64)
+	if err != nil || day < 1 || day >= 367 {
+		return fmt.Errorf("%w: epoch day %q", ErrFormat, line[20:32])
+	}
+	// two digit years from 57 on are in the 20th century
+	year += 2000
+	if year >= 2057 {
+		year -= 100
+	}
+	t.Epoch = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration((day - 1) * float64(24*time.Hour)))
+	if t.BStar, err = exponential(line[53:61]); err != nil {
+		return fmt.Errorf("%w: BSTAR %q", ErrFormat, line[53:61])
+	}
+	return nil
+}
 
-	id_string = strings.TrimSpace(id_string)
-	id, err := strconv.Atoi(id_string)
+func (t *TLE) parseLine2(line string) (err error) {
+	if err := checkLine(line, '2'); err != nil {
+		return err
+	}
+	t.Line2 = line
+	number, err := catalogNumber(line[2:7])
 	if err != nil {
-		log.Warn().Msgf("Could not parse id from title line: %s", title)
-		return rand.Intn(3000)
+		return err
+	}
+	if number != t.CatalogNumber {
+		return fmt.Errorf("%w: line 2 is of catalogue number %d, line 1 of %d", ErrFormat, number, t.CatalogNumber)
+	}
+	if t.MeanMotion, err = strconv.ParseFloat(strings.TrimSpace(line[52:63]), 64); err != nil || t.MeanMotion <= 0 {
+		return fmt.Errorf("%w: mean motion %q", ErrFormat, line[52:63])
 	}
-	return id
+	return nil
 }
 
-func LoadSatellites(filename string) (satelliteIds []int, satellites []gosat.Satellite, found bool) {
-	tledata, found := loadTLEdata(filename)
-	if !found {
-		return nil, nil, false
+// catalogNumber reads a five character catalogue number, in the Alpha-5 format above 99999: A to Z without I and O for 10 to 33
+func catalogNumber(field string) (int, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, fmt.Errorf("%w: empty catalogue number", ErrFormat)
+	}
+	prefix := 0
+	if c := field[0]; c >= 'A' && c <= 'Z' && c != 'I' && c != 'O' {
+		prefix = int(c-'A') + 10
+		if c > 'I' {
+			prefix--
+		}
+		if c > 'O' {
+			prefix--
+		}
+		field = field[1:]
+	}
+	number, err := strconv.Atoi(field)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("%w: catalogue number %q", ErrFormat, field)
 	}
-	// for each entry in slice of TLEdata structs (TLEdata keys: Line1, Line2, Title)
-	for _, tle := range tledata {
-		// Converts a two line element data set into a Satellite struct and runs initializes sgp4
-		satellite := gosat.TLEToSat(tle.Line1, tle.Line2, "wgs84")
-		satellites = append(satellites, satellite)
-		id := idFromTitleLine(tle.Title)
-		satelliteIds = append(satelliteIds, id)
-		// gosat.ParseTLE(tle.Line1, tle.Line2, "wgs84")
+	if prefix > 0 {
+		number += prefix * 10000
 	}
-	return satelliteIds, satellites, true
+	return number, nil
 }
 
-// lattitudes []float64
-// longitudes []float64
+// exponential reads a field in the assumed decimal point notation of the TLE format, " 34415-4" is 0.34415e-4
+func exponential(field string) (float64, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, nil
+	}
+	sign := 1.0
+	switch field[0] {
+	case '-':
+		sign, field = -1, field[1:]
+	case '+':
+		field = field[1:]
+	}
+	split := strings.LastIndexAny(field, "+-")
+	if split <= 0 {
+		return 0, fmt.Errorf("no exponent in %q", field)
+	}
+	mantissa, err := strconv.ParseFloat("0."+field[:split], 64)
+	if err != nil {
+		return 0, err
+	}
+	exponent, err := strconv.Atoi(field[split:])
+	if err != nil {
+		return 0, err
+	}
+	return sign * mantissa * math.Pow10(exponent), nil
+}
+
+// LoadSatellites reads the records of a TLE or OMM file and initialises SGP4 for every satellite.
+// The satellite ids are the NORAD catalogue numbers, which must be unique within the file.
+func LoadSatellites(filename string) (satelliteIds []int, satellites []gosat.Satellite, err error) {
+	records, err := ParseFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%s holds no two line elements", filename)
+	}
+	seen := make(map[int]int, len(records))
+	for _, record := range records {
+		if line, found := seen[record.CatalogNumber]; found {
+			return nil, nil, &ParseError{File: filename, Line: record.Line, Err: fmt.Errorf("%w: %d is also on line %d", ErrDuplicate, record.CatalogNumber, line)}
+		}
+		seen[record.CatalogNumber] = record.Line
+		satellites = append(satellites, record.Satellite())
+		satelliteIds = append(satelliteIds, record.CatalogNumber)
+	}
+	return satelliteIds, satellites, nil
+}
 
-func GetTLEfromNoradIDs(spacetrack *gosat.Spacetrack, norad_ids []int) (satellites []gosat.Satellite) {
+// GetTLEfromNoradIDs returns the latest element sets of the satellites before at from client, Space-Track or an offline Catalog
+func GetTLEfromNoradIDs(client Client, norad_ids []int, at time.Time) (satellites []gosat.Satellite, err error) {
 	for _, sattellite_id := range norad_ids {
-		sat, err := spacetrack.GetTLE(uint64(sattellite_id), time.Now(), "wgs84")
-		// Todo fixed time to make reproducible
+		sat, err := client.GetTLE(uint64(sattellite_id), at, gosat.GravityWGS84)
 		if err != nil {
-			log.Fatal().Err(err).Msg("DIE")
+			return nil, fmt.Errorf("element set of %d: %w", sattellite_id, err)
 		}
-		// log.Println(sat.Line1, sat.Line2)
 		satellites = append(satellites, sat)
 	}
-	return satellites
+	return satellites, nil
 }
@@ -0,0 +1,146 @@
+package tle
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	issLine1 = "1 25544U 98067A   23001.50000000  .00016717  00000-0  30306-3 0  9998"
+	issLine2 = "2 25544  51.6416 339.8014 0005220  59.9580  56.5163 15.49937564375169"
+)
+
+func TestParse(t *testing.T) {
+	records, err := Parse(strings.NewReader("0 ISS (ZARYA)\r\n"+issLine1+"\r\n"+issLine2+"\r\n"), "iss.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	iss := records[0]
+	if iss.Name != "ISS (ZARYA)" || iss.Line != 1 {
+		t.Errorf("expected ISS (ZARYA) on line 1, got %q on line %d", iss.Name, iss.Line)
+	}
+	if iss.CatalogNumber != 25544 {
+		t.Errorf("expected catalogue number 25544, got %d", iss.CatalogNumber)
+	}
+	if epoch := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC); !iss.Epoch.Equal(epoch) {
+		t.Errorf("expected epoch %s, got %s", epoch, iss.Epoch)
+	}
+	if math.Abs(iss.BStar-0.30306e-3) > 1e-12 {
+		t.Errorf("expected BSTAR 0.30306e-3, got %g", iss.BStar)
+	}
+	if iss.MeanMotion != 15.49937564 {
+		t.Errorf("expected mean motion 15.49937564, got %v", iss.MeanMotion)
+	}
+}
+
+func TestParseWithoutTitles(t *testing.T) {
+	records, err := Parse(strings.NewReader(issLine1+"\n"+issLine2+"\n\n"+issLine1+"\n"+issLine2+"\n"), "iss.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Name != "" || records[1].Line != 4 {
+		t.Errorf("expected an untitled second record on line 4, got %q on line %d", records[1].Name, records[1].Line)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	broken := issLine2[:len(issLine2)-1] + "4"
+	cases := []struct {
+		name  string
+		input string
+		line  int
+		err   error
+	}{
+		{"checksum", "ISS\n" + issLine1 + "\n" + broken + "\n", 3, ErrChecksum},
+		{"short line", "ISS\n" + issLine1[:60] + "\n" + issLine2 + "\n", 2, ErrFormat},
+		{"line 2 first", "ISS\n" + issLine2 + "\n" + issLine1 + "\n", 2, ErrLineNumber},
+		{"two line 1", issLine1 + "\n" + issLine1 + "\n", 2, ErrLineNumber},
+		{"missing line 2", "ISS\n" + issLine1 + "\n", 2, ErrLineNumber},
+		{"two titles", "ISS\nZARYA\n" + issLine1 + "\n" + issLine2 + "\n", 1, ErrFormat},
+	}
+	for _, c := range cases {
+		_, err := Parse(strings.NewReader(c.input), "iss.txt")
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+			continue
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a *ParseError, got %T", c.name, err)
+			continue
+		}
+		if parseErr.File != "iss.txt" || parseErr.Line != c.line {
+			t.Errorf("%s: expected iss.txt:%d, got %s", c.name, c.line, err)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	for _, line := range []string{issLine1, issLine2} {
+		if sum := Checksum(line[:lineLength-1]); sum != int(line[lineLength-1]-'0') {
+			t.Errorf("expected checksum %c, got %d", line[lineLength-1], sum)
+		}
+	}
+}
+
+func TestCatalogNumber(t *testing.T) {
+	cases := map[string]int{"25544": 25544, "00005": 5, "A0000": 100000, "H9999": 179999, "J0000": 180000, "Z9999": 339999}
+	for field, expected := range cases {
+		number, err := catalogNumber(field)
+		if err != nil {
+			t.Errorf("%s: %v", field, err)
+		} else if number != expected {
+			t.Errorf("%s: expected %d, got %d", field, expected, number)
+		}
+	}
+	if _, err := catalogNumber("I0000"); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected I0000 to be rejected, got %v", err)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	cases := map[string]float64{" 30306-3": 0.30306e-3, "-34415-4": -0.34415e-4, " 00000+0": 0, "+12345+1": 1.2345}
+	for field, expected := range cases {
+		value, err := exponential(field)
+		if err != nil {
+			t.Errorf("%q: %v", field, err)
+		} else if math.Abs(value-expected) > 1e-12 {
+			t.Errorf("%q: expected %g, got %g", field, expected, value)
+		}
+	}
+}
+
+func TestLoadSatellites(t *testing.T) {
+	ids, satellites, err := LoadSatellites("../OneWeb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 462 || len(satellites) != 462 {
+		t.Fatalf("expected 462 satellites, got %d ids and %d satellites", len(ids), len(satellites))
+	}
+	if ids[0] != 44057 {
+		t.Errorf("expected the first satellite to be 44057, got %d", ids[0])
+	}
+}
+
+func TestLoadSatellitesDuplicate(t *testing.T) {
+	filename := t.TempDir() + "/duplicate"
+	if err := os.WriteFile(filename, []byte("ISS\n"+issLine1+"\n"+issLine2+"\nZARYA\n"+issLine1+"\n"+issLine2+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := LoadSatellites(filename)
+	var parseErr *ParseError
+	if !errors.Is(err, ErrDuplicate) || !errors.As(err, &parseErr) || parseErr.Line != 4 {
+		t.Errorf("expected a duplicate on line 4, got %v", err)
+	}
+}
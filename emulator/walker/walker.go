@@ -0,0 +1,173 @@
+// Package walker generates the satellites of Walker star and Walker delta constellations on circular orbits
+package walker
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"project/space"
+)
+
+const (
+	Star  = "star"  // planes spread over 180 degrees of RAAN, e.g. polar constellations
+	Delta = "delta" // planes spread over 360 degrees of RAAN
+)
+
+const earthRadius = 6378.137 // km
+
+// Constellation is a Walker constellation i:T/P/F of Planes*SatellitesPerPlane satellites,
+// Phasing is F, the offset between neighbouring planes in units of 360/T degrees
+type Constellation struct {
+	Pattern            string  `json:"pattern" yaml:"pattern"`
+	Planes             int     `json:"planes" yaml:"planes"`
+	SatellitesPerPlane int     `json:"satellites_per_plane" yaml:"satellites_per_plane"`
+	Phasing            int     `json:"phasing" yaml:"phasing"`
+	Altitude           float64 `json:"altitude" yaml:"altitude"`       // km
+	Inclination        float64 `json:"inclination" yaml:"inclination"` // degrees
+}
+
+// Presets are the shells of the constellations the emulator knows by name
+var Presets = map[string]Constellation{
+	"Kepler":   {Pattern: Star, Planes: 7, SatellitesPerPlane: 20, Phasing: 0, Altitude: 600, Inclination: 98.6},
+	"OneWeb":   {Pattern: Star, Planes: 18, SatellitesPerPlane: 36, Phasing: 0, Altitude: 1200, Inclination: 87.9},
+	"Starlink": {Pattern: Delta, Planes: 72, SatellitesPerPlane: 22, Phasing: 1, Altitude: 550, Inclination: 53},
+}
+
+// Preset returns the preset constellation called name
+func Preset(name string) (Constellation, error) {
+	c, found := Presets[name]
+	if !found {
+		names := make([]string, 0, len(Presets))
+		for preset := range Presets {
+			names = append(names, preset)
+		}
+		sort.Strings(names)
+		return Constellation{}, fmt.Errorf("no walker preset %q, presets are %v", name, names)
+	}
+	return c, nil
+}
+
+// Size is the number of satellites in the constellation
+func (c Constellation) Size() int {
+	return c.Planes * c.SatellitesPerPlane
+}
+
+// Validate returns the first problem with the parameters, if any
+func (c Constellation) Validate() error {
+	switch {
+	case c.Pattern != Star && c.Pattern != Delta:
+		return fmt.Errorf("pattern %q must be %q or %q", c.Pattern, Star, Delta)
+	case c.Planes <= 0:
+		return fmt.Errorf("planes must be positive, got %d", c.Planes)
+	case c.SatellitesPerPlane <= 0:
+		return fmt.Errorf("satellites_per_plane must be positive, got %d", c.SatellitesPerPlane)
+	case c.Phasing < 0 || c.Phasing >= c.Planes:
+		return fmt.Errorf("phasing must be between 0 and planes-1 (%d), got %d", c.Planes-1, c.Phasing)
+	case c.Altitude <= 0:
+		return fmt.Errorf("altitude must be positive, got %g km", c.Altitude)
+	case c.Inclination < 0 || c.Inclination > 180:
+		return fmt.Errorf("inclination must be between 0 and 180 degrees, got %g", c.Inclination)
+	}
+	return nil
+}
+
+// Elements returns the circular orbits of all satellites at epoch, plane by plane
+func (c Constellation) Elements(epoch time.Time) []space.Elements {
+	spread := 2 * math.Pi
+	if c.Pattern == Star {
+		spread = math.Pi
+	}
+	elements := make([]space.Elements, 0, c.Size())
+	for plane := 0; plane < c.Planes; plane++ {
+		for slot := 0; slot < c.SatellitesPerPlane; slot++ {
+			elements = append(elements, space.Elements{
+				Epoch:         epoch,
+				SemiMajorAxis: earthRadius + c.Altitude,
+				Inclination:   c.Inclination * math.Pi / 180,
+				RAAN:          spread * float64(plane) / float64(c.Planes),
+				// circular orbits have no perigee, the mean anomaly is the argument of latitude
+				MeanAnomaly: 2*math.Pi*float64(slot)/float64(c.SatellitesPerPlane) + 2*math.Pi*float64(c.Phasing*plane)/float64(c.Size()),
+			})
+		}
+	}
+	return elements
+}
+
+// Propagators returns a propagator of model, space.ModelKepler or space.ModelJ2, for every satellite
+func (c Constellation) Propagators(model string, epoch time.Time) ([]space.Propagator, error) {
+	elements := c.Elements(epoch)
+	propagators := make([]space.Propagator, len(elements))
+	for i, e := range elements {
+		propagator, err := space.ElementsPropagator(model, e)
+		if err != nil {
+			return nil, err
+		}
+		propagators[i] = propagator
+	}
+	return propagators, nil
+}
+
+// Generate returns the positions of all satellites at steps points in time step apart, the first at start.
+// Satellite ids count up from 0 plane by plane and slot by slot.
+func (c Constellation) Generate(model string, start time.Time, step time.Duration, steps int) ([]space.OrbitalData, error) {
+	propagators, err := c.Propagators(model, start)
+	if err != nil {
+		return nil, err
+	}
+	satdata := make([]space.OrbitalData, len(propagators))
+	jobs := make(chan int, len(propagators))
+	for id := range propagators {
+		jobs <- id
+	}
+	close(jobs)
+	wg := sync.WaitGroup{}
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				satdata[id] = c.satellite(id, propagators[id], start, step, steps)
+			}
+		}()
+	}
+	wg.Wait()
+	return satdata, nil
+}
+
+// Provider serves steps steps of all satellites like Generate, propagating window steps at a time instead of all at once
+func (c Constellation) Provider(model string, start time.Time, step time.Duration, steps int, window int) (*space.StreamProvider, error) {
+	propagators, err := c.Propagators(model, start)
+	if err != nil {
+		return nil, err
+	}
+	satellites := make([]space.OrbitalData, len(propagators))
+	for id := range satellites {
+		satellites[id] = c.satellite(id, nil, start, step, 0)
+	}
+	return space.NewStreamProvider(satellites, propagators, start, step, steps, window), nil
+}
+
+func (c Constellation) satellite(id int, propagator space.Propagator, start time.Time, step time.Duration, steps int) space.OrbitalData {
+	sat := space.OrbitalData{
+		Isactive:    true,
+		SatelliteId: id,
+		Title:       strconv.Itoa(id),
+		Shell:       0, // a Walker constellation is a single shell
+		Plane:       id / c.SatellitesPerPlane,
+		Slot:        id % c.SatellitesPerPlane,
+		Position:    make([]space.Vector3, steps),
+		Velocity:    make([]space.Vector3, steps),
+		LatLong:     make([]space.LatLong, steps),
+	}
+	for i := 0; i < steps; i++ {
+		at := start.Add(time.Duration(i) * step)
+		sat.Position[i], sat.Velocity[i] = propagator.Propagate(at)
+		sat.LatLong[i] = space.LLAFromPosition(sat.Position[i], at)
+	}
+	return sat
+}
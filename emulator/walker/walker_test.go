@@ -0,0 +1,105 @@
+package walker
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"project/space"
+)
+
+var start = time.Date(2022, 9, 11, 12, 0, 0, 0, time.UTC)
+
+func TestPresets(t *testing.T) {
+	sizes := map[string]int{"Kepler": 140, "OneWeb": 648, "Starlink": 1584}
+	for name, size := range sizes {
+		c, err := Preset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if c.Size() != size {
+			t.Errorf("%s has %d satellites, expected %d", name, c.Size(), size)
+		}
+	}
+	if _, err := Preset("Iridium"); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []Constellation{
+		{Pattern: "rosette", Planes: 2, SatellitesPerPlane: 2, Altitude: 500},
+		{Pattern: Star, Planes: 0, SatellitesPerPlane: 2, Altitude: 500},
+		{Pattern: Delta, Planes: 2, SatellitesPerPlane: 2, Phasing: 2, Altitude: 500},
+		{Pattern: Delta, Planes: 2, SatellitesPerPlane: 2, Altitude: -1},
+		{Pattern: Delta, Planes: 2, SatellitesPerPlane: 2, Altitude: 500, Inclination: 200},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%+v should not be valid", c)
+		}
+	}
+}
+
+func TestGenerateOrbits(t *testing.T) {
+	c := Presets["Kepler"]
+	satdata, err := c.Generate(space.ModelKepler, start, 15*time.Second, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(satdata) != c.Size() {
+		t.Fatalf("generated %d satellites", len(satdata))
+	}
+	radius := earthRadius + c.Altitude
+	for _, sat := range satdata {
+		for i, position := range sat.Position {
+			if math.Abs(math.Sqrt(position.Dot(position))-radius) > 1e-6 {
+				t.Fatalf("satellite %d is not on its circular orbit at step %d", sat.SatelliteId, i)
+			}
+			// the velocity is perpendicular to the position on a circular orbit
+			if math.Abs(position.Dot(sat.Velocity[i])) > 1e-6 {
+				t.Fatalf("velocity of satellite %d is not perpendicular to its position", sat.SatelliteId)
+			}
+		}
+		// the velocity agrees with the movement between steps
+		moved := sat.Position[1].Distance(sat.Position[0])
+		speed := math.Sqrt(sat.Velocity[0].Dot(sat.Velocity[0]))
+		if math.Abs(moved-15*speed) > 0.1 {
+			t.Fatalf("satellite %d moved %f km in 15 s at %f km/s", sat.SatelliteId, moved, speed)
+		}
+	}
+}
+
+func TestGeneratePlanesAndSlots(t *testing.T) {
+	for _, c := range []Constellation{Presets["Kepler"], {Pattern: Delta, Planes: 6, SatellitesPerPlane: 8, Phasing: 1, Altitude: 550, Inclination: 53}} {
+		satdata, err := c.Generate(space.ModelJ2, start, time.Minute, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planes := space.OrbitalPlanes(satdata, 0)
+		if len(planes) != c.Planes {
+			t.Fatalf("%s: found %d planes, expected %d", c.Pattern, len(planes), c.Planes)
+		}
+		for p, plane := range planes {
+			if len(plane.Satellites) != c.SatellitesPerPlane {
+				t.Fatalf("%s: plane %d has %d satellites", c.Pattern, p, len(plane.Satellites))
+			}
+			for slot, i := range plane.Satellites {
+				// planes are found by RAAN and satellites by argument of latitude, starting at the first slot of the plane
+				first := plane.Satellites[0]
+				if satdata[i].Plane != satdata[first].Plane || satdata[i].Slot != (satdata[first].Slot+slot)%c.SatellitesPerPlane {
+					t.Errorf("%s: satellite %d in plane %d slot %d of the generator is at slot %d of plane %d", c.Pattern, i, satdata[i].Plane, satdata[i].Slot, slot, p)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateSGP4(t *testing.T) {
+	if _, err := Presets["Kepler"].Generate(space.ModelSGP4, start, time.Minute, 2); err == nil {
+		t.Error("walker constellations have no two line elements to propagate with SGP4")
+	}
+}